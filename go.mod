@@ -1,13 +1,13 @@
 module github.com/anuj-rajput/amazon-scraper
 
-go 1.19
+go 1.21
 
 require (
 	github.com/anaskhan96/soup v1.2.5
 	github.com/joho/godotenv v1.5.1
+	golang.org/x/net v0.15.0
+	golang.org/x/time v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	golang.org/x/net v0.15.0 // indirect
-	golang.org/x/text v0.13.0 // indirect
-)
+require golang.org/x/text v0.13.0 // indirect
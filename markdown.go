@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/anuj-rajput/amazon-scraper/scraper"
+)
+
+// Characters that would start markdown formatting inside a line of scraped text
+var markdownSpecial = regexp.MustCompile("[\\\\`*_\\[\\]<>|~#]")
+
+// Escape scraped text so it renders literally in markdown
+func escapeMarkdown(text string) string {
+	return markdownSpecial.ReplaceAllString(text, `\$0`)
+}
+
+// Render a result as a markdown report, products as a details table followed by their
+// reviews, review records as a list per product and search results as a table
+func renderMarkdown(v interface{}) (string, error) {
+	var b strings.Builder
+	switch v := v.(type) {
+	case scraper.Product:
+		writeProductMarkdown(&b, v)
+	case []scraper.ReviewRecord:
+		writeReviewRecordsMarkdown(&b, v)
+	case []scraper.SearchResult:
+		writeSearchResultsMarkdown(&b, v)
+	case []interface{}:
+		for i, item := range v {
+			if i > 0 {
+				b.WriteString("\n---\n\n")
+			}
+			section, err := renderMarkdown(item)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(section)
+		}
+	default:
+		return "", fmt.Errorf("markdown output isn't supported for %T", v)
+	}
+	return b.String(), nil
+}
+
+// Write a product's title, details table and, unless it has none, its reviews
+func writeProductMarkdown(b *strings.Builder, product scraper.Product) {
+	title := product.Title
+	if title == "" {
+		title = product.ASIN
+	}
+	fmt.Fprintf(b, "# %s\n\n", escapeMarkdown(title))
+
+	rating := ""
+	if product.Rating > 0 {
+		rating = fmt.Sprintf("%.1f / 5", product.Rating)
+	}
+	b.WriteString("| Detail | Value |\n|---|---|\n")
+	for _, row := range [][2]string{
+		{"ASIN", product.ASIN},
+		{"Price", product.Price},
+		{"Rating", rating},
+		{"Availability", product.Availability},
+		{"Brand", product.Brand},
+		{"URL", product.URL},
+	} {
+		if row[1] != "" {
+			fmt.Fprintf(b, "| %s | %s |\n", row[0], escapeMarkdown(row[1]))
+		}
+	}
+
+	if len(product.Reviews) > 0 {
+		b.WriteString("\n## Reviews\n\n")
+		for _, review := range product.Reviews {
+			writeReviewMarkdown(b, review)
+		}
+	}
+}
+
+// Write reviews-only output, grouped under a heading per product in the order they came
+func writeReviewRecordsMarkdown(b *strings.Builder, records []scraper.ReviewRecord) {
+	if len(records) == 0 {
+		b.WriteString("No reviews found.\n")
+		return
+	}
+	for i, record := range records {
+		if i == 0 || record.ASIN != records[i-1].ASIN || record.Domain != records[i-1].Domain {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(b, "# Reviews of %s on %s\n\n", record.ASIN, record.Domain)
+		}
+		writeReviewMarkdown(b, record.Review)
+	}
+}
+
+// Write a review as a bullet with its rating, title and author, and its text indented below
+func writeReviewMarkdown(b *strings.Builder, review scraper.Review) {
+	fmt.Fprintf(b, "- **%.1f / 5**", review.Rating)
+	if review.Title != "" {
+		fmt.Fprintf(b, " %s", escapeMarkdown(review.Title))
+	}
+	if review.Author != "" {
+		fmt.Fprintf(b, " by %s", escapeMarkdown(review.Author))
+	}
+	if review.Verified {
+		b.WriteString(" (verified purchase)")
+	}
+	b.WriteString("\n")
+	if review.Content != "" {
+		fmt.Fprintf(b, "  %s\n", escapeMarkdown(review.Content))
+	}
+}
+
+// Write search results as a table, sponsored results marked as such
+func writeSearchResultsMarkdown(b *strings.Builder, results []scraper.SearchResult) {
+	b.WriteString("| ASIN | Title | Price | Rating |\n|---|---|---|---|\n")
+	for _, result := range results {
+		title := escapeMarkdown(result.Title)
+		if result.Sponsored {
+			title += " (sponsored)"
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", result.ASIN, title, escapeMarkdown(result.Price), escapeMarkdown(result.Rating))
+	}
+}
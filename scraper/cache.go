@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Path of the cache file for a URL, named by the SHA-256 of the URL
+func cachePath(url string, opts Options) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(opts.CacheDir, hex.EncodeToString(sum[:])+".html")
+}
+
+// Check whether a page would be served from the cache without a request
+func isCached(url string, opts Options) bool {
+	if opts.CacheDir == "" {
+		return false
+	}
+
+	info, err := os.Stat(cachePath(url, opts))
+	if err != nil {
+		return false
+	}
+	return opts.CacheTTL <= 0 || time.Since(info.ModTime()) <= opts.CacheTTL
+}
+
+// Read a cached page, missing or stale entries are reported as misses
+func readCache(url string, opts Options) (string, bool) {
+	if !isCached(url, opts) {
+		return "", false
+	}
+
+	data, err := os.ReadFile(cachePath(url, opts))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Store a fetched page in the cache, failures only cost a refetch next time
+func writeCache(url string, html string, opts Options) {
+	if opts.CacheDir == "" {
+		return
+	}
+
+	if err := os.MkdirAll(opts.CacheDir, 0755); err != nil {
+		slog.Warn("Couldn't create cache directory", "dir", opts.CacheDir, "error", err)
+		return
+	}
+
+	// Write to a temporary file and rename it into place so concurrent
+	// workers never read a partially written entry
+	tmp, err := os.CreateTemp(opts.CacheDir, "*.tmp")
+	if err != nil {
+		slog.Warn("Couldn't write cache entry", "url", url, "error", err)
+		return
+	}
+	_, err = tmp.WriteString(html)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), cachePath(url, opts))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		slog.Warn("Couldn't write cache entry", "url", url, "error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Jar used when Options.Cookies is nil, so cookies Amazon sets persist for the whole process
+var (
+	defaultJarOnce sync.Once
+	defaultJar     http.CookieJar
+)
+
+// Get the jar requests should use, the shared default one unless opts.Cookies is set
+func cookieJar(opts Options) http.CookieJar {
+	if opts.Cookies != nil {
+		return opts.Cookies
+	}
+	defaultJarOnce.Do(func() {
+		defaultJar, _ = cookiejar.New(nil)
+	})
+	return defaultJar
+}
+
+// NewCookieJar creates a cookie jar seeded from either a "name=value; name2=value2"
+// string, sent to every supported marketplace, or the path of a Netscape cookie file.
+// An empty string gives an empty jar that still keeps the cookies Amazon sets
+func NewCookieJar(cookies string) (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cookies = strings.TrimSpace(cookies)
+	if cookies == "" {
+		return jar, nil
+	}
+	if info, err := os.Stat(cookies); err == nil && !info.IsDir() {
+		return jar, LoadCookieFile(jar, cookies)
+	}
+
+	var parsed []*http.Cookie
+	for _, pair := range strings.Split(cookies, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid cookie %q, expected name=value", pair)
+		}
+		parsed = append(parsed, &http.Cookie{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	for _, domain := range marketplaceDomains() {
+		domainCookies := make([]*http.Cookie, 0, len(parsed))
+		for _, cookie := range parsed {
+			domainCookie := *cookie
+			domainCookie.Domain, domainCookie.Path = domain, "/"
+			domainCookies = append(domainCookies, &domainCookie)
+		}
+		jar.SetCookies(&url.URL{Scheme: "https", Host: "www." + domain, Path: "/"}, domainCookies)
+	}
+	return jar, nil
+}
+
+// LoadCookieFile adds the cookies of a Netscape cookie file, as exported by browsers and curl,
+// to a jar. Each line holds one tab-separated cookie: domain, include subdomains, path,
+// secure, expiry, name and value
+func LoadCookieFile(jar http.CookieJar, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		// HttpOnly cookies are written as comments with a #HttpOnly_ prefix
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("%s:%d: expected 7 tab-separated fields, got %d", path, i+1, len(fields))
+		}
+		cookie := &http.Cookie{
+			Path:   fields[2],
+			Secure: strings.EqualFold(fields[3], "TRUE"),
+			Name:   fields[5],
+			Value:  fields[6],
+		}
+		// Host-only cookies are stored without a Domain so subdomains don't get them
+		host := strings.TrimPrefix(fields[0], ".")
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = host
+		}
+		// Session cookies have an expiry of 0
+		if expiry, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+		jar.SetCookies(&url.URL{Scheme: "https", Host: host, Path: "/"}, []*http.Cookie{cookie})
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateSource provides exchange rates for converting prices between currencies
+type RateSource interface {
+	// Rate returns how many units of to one unit of from is worth
+	Rate(from string, to string) (float64, error)
+}
+
+// StaticRates are exchange rates relative to a base currency, e.g. loaded with LoadRatesFile
+type StaticRates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// LoadRatesFile reads rates from a JSON file such as {"base": "USD", "rates": {"EUR": 0.92, "GBP": 0.79}}
+func LoadRatesFile(path string) (StaticRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return StaticRates{}, err
+	}
+
+	var rates StaticRates
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return StaticRates{}, fmt.Errorf("invalid rates file %s: %v", path, err)
+	}
+	if rates.Base == "" || len(rates.Rates) == 0 {
+		return StaticRates{}, fmt.Errorf("invalid rates file %s: missing base or rates", path)
+	}
+	return rates, nil
+}
+
+// Rate converts through the base currency, so any two listed currencies can be converted
+func (r StaticRates) Rate(from string, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	lookup := func(currency string) (float64, bool) {
+		if currency == strings.ToUpper(r.Base) {
+			return 1, true
+		}
+		rate, ok := r.Rates[currency]
+		return rate, ok && rate > 0
+	}
+
+	fromRate, ok := lookup(from)
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for %s", from)
+	}
+	toRate, ok := lookup(to)
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for %s", to)
+	}
+	return toRate / fromRate, nil
+}
+
+// APIRates fetches rates from an exchange-rate API, once per base currency per process.
+// URL contains {base} where the source currency goes, e.g.
+// https://open.er-api.com/v6/latest/{base}, and the response needs a "rates" object
+type APIRates struct {
+	URL string
+
+	mu      sync.Mutex
+	fetched map[string]map[string]float64
+}
+
+// Rate fetches the rates for from on first use and looks up to in them
+func (a *APIRates) Rate(from string, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rates, ok := a.fetched[from]
+	if !ok {
+		var err error
+		rates, err = fetchAPIRates(strings.ReplaceAll(a.URL, "{base}", from))
+		if err != nil {
+			return 0, err
+		}
+		if a.fetched == nil {
+			a.fetched = map[string]map[string]float64{}
+		}
+		a.fetched[from] = rates
+	}
+
+	rate, ok := rates[to]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no exchange rate from %s to %s", from, to)
+	}
+	return rate, nil
+}
+
+// Fetch the "rates" object of an exchange-rate API response
+func fetchAPIRates(url string) (map[string]float64, error) {
+	client := &http.Client{Timeout: time.Second * 30}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("exchange rate API returned status code: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid exchange rate API response: %v", err)
+	}
+	if len(body.Rates) == 0 {
+		return nil, fmt.Errorf("exchange rate API response has no rates")
+	}
+	return body.Rates, nil
+}
+
+// ConvertPrice sets PriceConverted and ConvertedCurrency from the product's current
+// price, leaving the original price fields untouched. Products without a parsed
+// price or currency are left as they are
+func ConvertPrice(product *Product, to string, rates RateSource) error {
+	if product.Pricing == nil || product.Pricing.Currency == "" || to == "" {
+		return nil
+	}
+
+	to = strings.ToUpper(to)
+	rate := 1.0
+	if product.Pricing.Currency != to {
+		var err error
+		rate, err = rates.Rate(product.Pricing.Currency, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	product.PriceConverted = math.Round(product.Pricing.Current*rate*100) / 100
+	product.ConvertedCurrency = to
+	return nil
+}
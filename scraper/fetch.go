@@ -0,0 +1,346 @@
+package scraper
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCaptcha is returned when Amazon serves a robot check page instead of the requested content
+var ErrCaptcha = errors.New("amazon served a captcha / robot check page")
+
+// Markers that only appear on Amazon's robot check pages (matched lowercase)
+var captchaMarkers = []string{
+	"/errors/validatecaptcha",
+	"captchacharacters",
+	"type the characters you see in this image",
+	"<title>robot check</title>",
+	"api-services-support@amazon.com",
+	"id=\"errorscontainer\"",
+}
+
+// Client timeout when Options.Timeout is 0
+const defaultTimeout = 30 * time.Second
+
+// Returned by fetchHTML for 404 responses so callers can tell a missing page from other failures
+var errPageNotFound = errors.New("received non-200 status code: 404")
+
+// DefaultUserAgents is the pool of realistic desktop and mobile browser User-Agent
+// strings rotated through when Options.UserAgents is empty
+var DefaultUserAgents = []string{
+	// Chrome
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/128.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.6668.81 Mobile Safari/537.36",
+	// Firefox
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:131.0) Gecko/20100101 Firefox/131.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.7; rv:131.0) Gecko/20100101 Firefox/131.0",
+	"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:130.0) Gecko/20100101 Firefox/130.0",
+	// Safari
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Safari/605.1.15",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 18_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (iPad; CPU OS 17_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.6 Mobile/15E148 Safari/604.1",
+	// Edge
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36 Edg/129.0.0.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36 Edg/129.0.0.0",
+}
+
+// Index of the proxy in Options.Proxies currently in use, shared by all
+// requests so a proxy that got a captcha isn't tried again for the next page
+var (
+	proxyIndex   int
+	proxyIndexMu sync.Mutex
+)
+
+// Get the proxy for the next request, nil for a direct connection
+func currentProxy(opts Options) *url.URL {
+	if len(opts.Proxies) == 0 {
+		return opts.Proxy
+	}
+	proxyIndexMu.Lock()
+	defer proxyIndexMu.Unlock()
+	return opts.Proxies[proxyIndex%len(opts.Proxies)]
+}
+
+// Move on to the proxy after the given one, unless another request already has
+func rotateProxy(opts Options, used *url.URL) *url.URL {
+	if len(opts.Proxies) == 0 {
+		return opts.Proxy
+	}
+	proxyIndexMu.Lock()
+	defer proxyIndexMu.Unlock()
+	if opts.Proxies[proxyIndex%len(opts.Proxies)] == used {
+		proxyIndex++
+	}
+	return opts.Proxies[proxyIndex%len(opts.Proxies)]
+}
+
+// Transports by proxy URL, "" for a direct connection, shared by every client so
+// connections are reused instead of each request leaving its own idle one behind
+var transports sync.Map
+
+// Get the shared transport for a proxy, creating it on first use
+func proxyTransport(proxy *url.URL) *http.Transport {
+	key := ""
+	if proxy != nil {
+		key = proxy.String()
+	}
+	if transport, ok := transports.Load(key); ok {
+		return transport.(*http.Transport)
+	}
+
+	// Route through the proxy, credentials in the URL are sent as proxy auth
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	actual, _ := transports.LoadOrStore(key, transport)
+	return actual.(*http.Transport)
+}
+
+// Create HTTP client with custom headers to avoid detection
+func createHTTPClient(opts Options) *http.Client {
+	transport := proxyTransport(currentProxy(opts))
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		Jar:       cookieJar(opts),
+	}
+}
+
+// Pick the User-Agent for the next request
+func pickUserAgent(opts Options) string {
+	if agent := pinnedUserAgent(opts); agent != "" {
+		return agent
+	}
+
+	agents := opts.UserAgents
+	if len(agents) == 0 {
+		agents = DefaultUserAgents
+	}
+	if !opts.RandomUA {
+		return agents[0]
+	}
+	return agents[rand.Intn(len(agents))]
+}
+
+// Pick a User-Agent other than the one that was just used, unless one is pinned.
+// A pool with nothing else in it keeps the one that was used
+func freshUserAgent(opts Options, used string) string {
+	if agent := pinnedUserAgent(opts); agent != "" {
+		return agent
+	}
+
+	agents := opts.UserAgents
+	if len(agents) == 0 {
+		agents = DefaultUserAgents
+	}
+	var others []string
+	for _, agent := range agents {
+		if agent != used {
+			others = append(others, agent)
+		}
+	}
+	if len(others) == 0 {
+		return used
+	}
+	return others[rand.Intn(len(others))]
+}
+
+// The User-Agent set by the caller, either directly or as a custom header, which
+// would override whatever the rotation picked
+func pinnedUserAgent(opts Options) string {
+	if opts.UserAgent != "" {
+		return opts.UserAgent
+	}
+	return opts.Headers.Get("User-Agent")
+}
+
+// Create request with custom headers
+func createRequest(url string, opts Options) (*http.Request, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the Accept-Language of the marketplace, English for unknown hosts
+	acceptLanguage := "en-US,en;q=0.5"
+	if m, ok := hostMarketplace(req.URL.Hostname()); ok {
+		acceptLanguage = m.acceptLanguage
+	}
+
+	// Add headers to mimic a real browser
+	req.Header.Set("User-Agent", pickUserAgent(opts))
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+	req.Header.Set("Accept-Language", acceptLanguage)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+	req.Header.Set("Cache-Control", "max-age=0")
+
+	// Custom headers take precedence over the defaults above
+	for key, values := range opts.Headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+
+	return req, nil
+}
+
+// Fetch the HTML content of a page, from the cache when enabled. ctx cancels the
+// request, the rate limit wait and any captcha retries
+func fetchHTML(ctx context.Context, url string, opts Options) (string, error) {
+	return fetchHTMLAs(ctx, url, opts, pickUserAgent(opts))
+}
+
+// Fetch a page like fetchHTML, starting out with the given User-Agent
+func fetchHTMLAs(ctx context.Context, url string, opts Options, userAgent string) (string, error) {
+	if html, ok := readCache(url, opts); ok {
+		slog.Debug("Serving page from cache", "url", url)
+		return html, nil
+	}
+
+	if !opts.IgnoreRobots {
+		allowed, err := robotsAllowed(url, opts)
+		if err != nil {
+			return "", err
+		}
+		if !allowed {
+			return "", fmt.Errorf("%w: %s", ErrDisallowedByRobots, url)
+		}
+	}
+
+	// Robot checks are usually tied to the proxy and User-Agent, so retry with the
+	// next proxy and a different User-Agent before giving up. Both are settled
+	// before each attempt so the retry knows which ones to move away from
+	attemptOpts := opts
+	attemptOpts.UserAgent = userAgent
+	for attempt := 0; ; attempt++ {
+		used := currentProxy(opts)
+		attemptOpts.Proxy, attemptOpts.Proxies = used, nil
+		html, err := fetchPage(ctx, url, attemptOpts)
+		if !errors.Is(err, ErrCaptcha) || attempt >= opts.Retries || ctx.Err() != nil {
+			if err == nil {
+				writeCache(url, html, opts)
+			}
+			return html, err
+		}
+
+		proxy := rotateProxy(opts, used)
+		attemptOpts.UserAgent = freshUserAgent(opts, attemptOpts.UserAgent)
+		slog.Debug("Captcha served, retrying with a new identity", "url", url, "attempt", attempt+1,
+			"retries", opts.Retries, "proxy", redactedProxy(proxy), "user_agent", attemptOpts.UserAgent)
+	}
+}
+
+// Fetch a page once, without the cache or robots.txt check
+func fetchPage(ctx context.Context, url string, opts Options) (string, error) {
+	client := createHTTPClient(opts)
+	req, err := createRequest(url, opts)
+	if err != nil {
+		return "", err
+	}
+
+	// Cache hits never count against the rate limit
+	if err := waitForRateLimit(ctx, url, opts); err != nil {
+		return "", err
+	}
+
+	// The deadline starts after the rate limit wait, so only a slow response runs it out
+	if opts.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.RequestTimeout)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		slog.Debug("Fetch failed", "url", url, "error", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+	slog.Debug("Fetched page", "url", url, "status", resp.StatusCode, "elapsed", time.Since(start))
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errPageNotFound
+	}
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+	}
+
+	// Read the response body, decompressing it ourselves since setting
+	// Accept-Encoding turns off the transport's transparent gzip handling
+	reader, err := decompressBody(resp)
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	// Robot checks come back as 200 and would otherwise parse as an empty page
+	if isCaptchaPage(string(body)) {
+		return "", ErrCaptcha
+	}
+
+	return string(body), nil
+}
+
+// Describe a proxy for logging without its credentials
+func redactedProxy(proxy *url.URL) string {
+	if proxy == nil {
+		return "direct"
+	}
+	return proxy.Redacted()
+}
+
+// Wrap a response body in the decoder matching its Content-Encoding
+func decompressBody(resp *http.Response) (io.Reader, error) {
+	// Already decoded by the transport, decoding again would fail
+	if resp.Uncompressed {
+		return resp.Body, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		// Servers send either zlib-wrapped or raw deflate data for "deflate"
+		buffered := bufio.NewReader(resp.Body)
+		header, err := buffered.Peek(2)
+		if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(buffered)
+		}
+		return flate.NewReader(buffered), nil
+	}
+	return resp.Body, nil
+}
+
+// Check whether a page is Amazon's robot check instead of real content
+func isCaptchaPage(html string) bool {
+	lower := strings.ToLower(html)
+	for _, marker := range captchaMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
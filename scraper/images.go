@@ -0,0 +1,149 @@
+package scraper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DownloadReviewImages saves the images of each review into dir, named by review ID and
+// index such as "R2XJ0KDBC1ZQ8V-1.jpg", and records the local files in ImagePaths.
+// Files that already exist are not downloaded again. Downloads go through the shared
+// rate limiter, at most opts.Concurrency at a time, and failed ones are left out of
+// ImagePaths and reported together in the returned error
+func DownloadReviewImages(reviews []Review, dir string, opts Options) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	type job struct {
+		review int
+		index  int
+		url    string
+		path   string
+	}
+	var jobs []job
+	for i := range reviews {
+		reviews[i].ImagePaths = make([]string, len(reviews[i].Images))
+		for j, imageURL := range reviews[i].Images {
+			jobs = append(jobs, job{review: i, index: j, url: imageURL, path: filepath.Join(dir, reviewImageName(reviews[i], j, imageURL))})
+		}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var mu sync.Mutex
+	var failed []string
+	queue := make(chan job)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				err := downloadImage(j.url, j.path, opts)
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, fmt.Sprintf("%s: %v", j.url, err))
+				} else {
+					reviews[j.review].ImagePaths[j.index] = j.path
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	// Drop the slots of failed downloads so ImagePaths only lists files on disk
+	for i := range reviews {
+		paths := reviews[i].ImagePaths[:0]
+		for _, p := range reviews[i].ImagePaths {
+			if p != "" {
+				paths = append(paths, p)
+			}
+		}
+		reviews[i].ImagePaths = paths
+		if len(paths) == 0 {
+			reviews[i].ImagePaths = nil
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to download %d of %d review images: %s", len(failed), len(jobs), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// Name the file for a review image, falling back to a hash of the URL for reviews without an ID
+func reviewImageName(review Review, index int, imageURL string) string {
+	ext := strings.ToLower(path.Ext(strings.SplitN(imageURL, "?", 2)[0]))
+	if ext == "" || len(ext) > 5 {
+		ext = ".jpg"
+	}
+	id := review.ID
+	if id == "" {
+		sum := sha256.Sum256([]byte(imageURL))
+		id = hex.EncodeToString(sum[:8])
+	}
+	return fmt.Sprintf("%s-%d%s", id, index+1, ext)
+}
+
+// Download an image to path unless it's already there, writing through a temporary file
+func downloadImage(imageURL string, dest string, opts Options) error {
+	if _, err := os.Stat(dest); err == nil {
+		slog.Debug("Review image already downloaded", "path", dest)
+		return nil
+	}
+
+	req, err := http.NewRequest("GET", imageURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", pickUserAgent(opts))
+
+	if err := waitForRateLimit(context.Background(), imageURL, opts); err != nil {
+		return err
+	}
+	resp, err := createHTTPClient(opts).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmp, resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dest)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	slog.Debug("Downloaded review image", "url", imageURL, "path", dest)
+	return nil
+}
@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloadReviewImages(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/missing.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("image " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	opts := Options{RPS: -1, Concurrency: 2}
+	reviews := []Review{
+		{ID: "R1ABC", Images: []string{server.URL + "/a.jpg", server.URL + "/b.png?x=1"}},
+		{ID: "R2DEF", Images: []string{server.URL + "/missing.jpg"}},
+		{ID: "R3GHI"},
+	}
+
+	if err := DownloadReviewImages(reviews, dir, opts); err == nil {
+		t.Error("DownloadReviewImages() error = nil, want the failed download reported")
+	}
+
+	want := []string{filepath.Join(dir, "R1ABC-1.jpg"), filepath.Join(dir, "R1ABC-2.png")}
+	if len(reviews[0].ImagePaths) != 2 || reviews[0].ImagePaths[0] != want[0] || reviews[0].ImagePaths[1] != want[1] {
+		t.Fatalf("ImagePaths = %v, want %v", reviews[0].ImagePaths, want)
+	}
+	if data, err := os.ReadFile(want[0]); err != nil || string(data) != "image /a.jpg" {
+		t.Errorf("%s = %q, %v", want[0], data, err)
+	}
+	if reviews[1].ImagePaths != nil || reviews[2].ImagePaths != nil {
+		t.Errorf("ImagePaths of failed and imageless reviews = %v, %v, want nil", reviews[1].ImagePaths, reviews[2].ImagePaths)
+	}
+
+	// Files already on disk are recorded without downloading them again
+	requests.Store(0)
+	reviews[1].Images = nil
+	if err := DownloadReviewImages(reviews, dir, opts); err != nil {
+		t.Fatalf("DownloadReviewImages() again error: %v", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("second run made %d requests, want 0", n)
+	}
+	if len(reviews[0].ImagePaths) != 2 {
+		t.Errorf("ImagePaths after second run = %v", reviews[0].ImagePaths)
+	}
+}
+
+func TestParseReviewPageID(t *testing.T) {
+	reviews := parseReviewPage(`<div id="R2XJ0KDBC1ZQ8V" data-hook="review"><span class="a-profile-name">Jane</span></div>`)
+	if len(reviews) != 1 || reviews[0].ID != "R2XJ0KDBC1ZQ8V" {
+		t.Errorf("parseReviewPage() = %+v, want ID R2XJ0KDBC1ZQ8V", reviews)
+	}
+}
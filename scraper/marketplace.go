@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownRegion is returned when a region doesn't name an Amazon marketplace
+var ErrUnknownRegion = errors.New("unknown Amazon region")
+
+// Marketplace settings that differ per Amazon domain
+type marketplace struct {
+	currency       string
+	acceptLanguage string
+}
+
+// Amazon marketplaces we know how to scrape, keyed by domain
+var marketplaces = map[string]marketplace{
+	"amazon.com":    {"USD", "en-US,en;q=0.5"},
+	"amazon.co.uk":  {"GBP", "en-GB,en;q=0.9"},
+	"amazon.de":     {"EUR", "de-DE,de;q=0.9,en;q=0.8"},
+	"amazon.fr":     {"EUR", "fr-FR,fr;q=0.9,en;q=0.8"},
+	"amazon.it":     {"EUR", "it-IT,it;q=0.9,en;q=0.8"},
+	"amazon.es":     {"EUR", "es-ES,es;q=0.9,en;q=0.8"},
+	"amazon.nl":     {"EUR", "nl-NL,nl;q=0.9,en;q=0.8"},
+	"amazon.co.jp":  {"JPY", "ja-JP,ja;q=0.9,en;q=0.8"},
+	"amazon.ca":     {"CAD", "en-CA,en;q=0.9,fr-CA;q=0.8"},
+	"amazon.com.br": {"BRL", "pt-BR,pt;q=0.9,en;q=0.8"},
+	"amazon.com.mx": {"MXN", "es-MX,es;q=0.9,en;q=0.8"},
+	"amazon.se":     {"SEK", "sv-SE,sv;q=0.9,en;q=0.8"},
+	"amazon.com.au": {"AUD", "en-AU,en;q=0.9"},
+	"amazon.in":     {"INR", "en-IN,en;q=0.9,hi;q=0.8"},
+	"amazon.com.tr": {"TRY", "tr-TR,tr;q=0.9,en;q=0.8"},
+	"amazon.sa":     {"SAR", "ar-SA,ar;q=0.9,en;q=0.8"},
+	"amazon.ae":     {"AED", "ar-AE,ar;q=0.9,en;q=0.8"},
+	"amazon.pl":     {"PLN", "pl-PL,pl;q=0.9,en;q=0.8"},
+	"amazon.com.be": {"EUR", "nl-BE,fr-BE;q=0.9,nl;q=0.8,fr;q=0.7,en;q=0.6"},
+	"amazon.sg":     {"SGD", "en-SG,en;q=0.9"},
+}
+
+// Shorthands for marketplaces whose TLD isn't the country code alone
+var regionAliases = map[string]string{
+	"us": "com",
+	"uk": "co.uk",
+	"gb": "co.uk",
+	"jp": "co.jp",
+	"br": "com.br",
+	"mx": "com.mx",
+	"au": "com.au",
+	"tr": "com.tr",
+	"be": "com.be",
+}
+
+// RegionDomain turns a region such as "de", "uk", "co.uk" or "amazon.de" into
+// its Amazon domain, returning ErrUnknownRegion for anything that isn't a known marketplace
+func RegionDomain(region string) (string, error) {
+	domain := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(region)), "www.")
+	if alias, ok := regionAliases[domain]; ok {
+		domain = alias
+	}
+	if !strings.HasPrefix(domain, "amazon.") {
+		domain = "amazon." + domain
+	}
+
+	if _, ok := marketplaces[domain]; !ok {
+		return "", fmt.Errorf("%w %q, use one of: %s", ErrUnknownRegion, region, strings.Join(marketplaceDomains(), ", "))
+	}
+	return domain, nil
+}
+
+// Known marketplace domains in alphabetical order
+func marketplaceDomains() []string {
+	domains := make([]string, 0, len(marketplaces))
+	for domain := range marketplaces {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// Find the marketplace for a host such as "www.amazon.de" or "smile.amazon.com"
+func hostMarketplace(host string) (marketplace, bool) {
+	host = strings.ToLower(host)
+	if i := strings.Index(host, "amazon."); i >= 0 {
+		host = host[i:]
+	}
+	m, ok := marketplaces[host]
+	return m, ok
+}
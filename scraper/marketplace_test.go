@@ -0,0 +1,30 @@
+package scraper
+
+import "testing"
+
+func TestAcceptLanguagePerMarketplace(t *testing.T) {
+	tests := []struct {
+		region string
+		domain string
+		want   string
+	}{
+		{"tr", "amazon.com.tr", "tr-TR,tr;q=0.9,en;q=0.8"},
+		{"sa", "amazon.sa", "ar-SA,ar;q=0.9,en;q=0.8"},
+		{"ae", "amazon.ae", "ar-AE,ar;q=0.9,en;q=0.8"},
+		{"pl", "amazon.pl", "pl-PL,pl;q=0.9,en;q=0.8"},
+		{"be", "amazon.com.be", "nl-BE,fr-BE;q=0.9,nl;q=0.8,fr;q=0.7,en;q=0.6"},
+		{"sg", "amazon.sg", "en-SG,en;q=0.9"},
+	}
+	for _, tt := range tests {
+		if domain, err := RegionDomain(tt.region); err != nil || domain != tt.domain {
+			t.Errorf("RegionDomain(%q) = %q, %v, want %q", tt.region, domain, err, tt.domain)
+		}
+		req, err := createRequest("https://www."+tt.domain+"/dp/B08N5WRWNW", Options{})
+		if err != nil {
+			t.Fatalf("createRequest() for %s error: %v", tt.domain, err)
+		}
+		if got := req.Header.Get("Accept-Language"); got != tt.want {
+			t.Errorf("Accept-Language for %s = %q, want %q", tt.domain, got, tt.want)
+		}
+	}
+}
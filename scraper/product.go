@@ -0,0 +1,1270 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anaskhan96/soup"
+	"golang.org/x/net/html"
+)
+
+// ErrProductNotFound is returned when Amazon has no product page for the ASIN
+var ErrProductNotFound = errors.New("product not found, check the ASIN")
+
+// ErrEmptyProduct is returned when a fetched page has no title, price or rating,
+// usually an interstitial or ad placeholder rather than the product
+var ErrEmptyProduct = errors.New("page has no product title, price or rating")
+
+// Product represents Amazon product information
+type Product struct {
+	ASIN               string            `json:"asin" yaml:"asin"`
+	URL                string            `json:"url" yaml:"url"`
+	Title              string            `json:"title" yaml:"title"`
+	Brand              string            `json:"brand,omitempty" yaml:"brand,omitempty"`
+	Manufacturer       string            `json:"manufacturer,omitempty" yaml:"manufacturer,omitempty"`
+	Price              string            `json:"price" yaml:"price"`
+	Pricing            *Pricing          `json:"pricing,omitempty" yaml:"pricing,omitempty"`
+	PriceMin           float64           `json:"price_min,omitempty" yaml:"price_min,omitempty"`
+	PriceMax           float64           `json:"price_max,omitempty" yaml:"price_max,omitempty"`
+	PriceConverted     float64           `json:"price_converted,omitempty" yaml:"price_converted,omitempty"`
+	ConvertedCurrency  string            `json:"converted_currency,omitempty" yaml:"converted_currency,omitempty"`
+	Coupon             Coupon            `json:"coupon" yaml:"coupon"`
+	Deal               string            `json:"deal,omitempty" yaml:"deal,omitempty"`
+	Rating             float64           `json:"rating" yaml:"rating"`
+	RatingBreakdown    map[int]float64   `json:"rating_breakdown,omitempty" yaml:"rating_breakdown,omitempty"`
+	QuestionCount      int               `json:"question_count" yaml:"question_count"`
+	BestSellersRank    []RankEntry       `json:"best_sellers_rank,omitempty" yaml:"best_sellers_rank,omitempty"`
+	Categories         []string          `json:"categories,omitempty" yaml:"categories,omitempty"`
+	Description        string            `json:"description" yaml:"description"`
+	Details            map[string]string `json:"details,omitempty" yaml:"details,omitempty"`
+	DateFirstAvailable time.Time         `json:"date_first_available" yaml:"date_first_available"`
+	ShippingWeight     string            `json:"shipping_weight,omitempty" yaml:"shipping_weight,omitempty"`
+	Availability       string            `json:"availability,omitempty" yaml:"availability,omitempty"`
+	InStock            bool              `json:"in_stock" yaml:"in_stock"`
+	StockCount         int               `json:"stock_count,omitempty" yaml:"stock_count,omitempty"`
+	Seller             SellerInfo        `json:"seller" yaml:"seller"`
+	Shipping           ShippingInfo      `json:"shipping" yaml:"shipping"`
+	Exclusive          bool              `json:"exclusive" yaml:"exclusive"`
+	ExclusiveLabel     string            `json:"exclusive_label,omitempty" yaml:"exclusive_label,omitempty"`
+	MainImage          string            `json:"main_image,omitempty" yaml:"main_image,omitempty"`
+	Images             []string          `json:"images,omitempty" yaml:"images,omitempty"`
+	ParentASIN         string            `json:"parent_asin,omitempty" yaml:"parent_asin,omitempty"`
+	SelectedVariation  map[string]string `json:"selected_variation,omitempty" yaml:"selected_variation,omitempty"`
+	Variants           []Variant         `json:"variants,omitempty" yaml:"variants,omitempty"`
+	RelatedASINs       []string          `json:"related_asins,omitempty" yaml:"related_asins,omitempty"`
+	ReviewSummary      *ReviewSummary    `json:"review_summary,omitempty" yaml:"review_summary,omitempty"`
+	Reviews            []Review          `json:"reviews,omitempty" yaml:"reviews,omitempty"`
+}
+
+// Pricing is the structured form of a product's price, List equals Current when there's no discount
+type Pricing struct {
+	Current     float64 `json:"current" yaml:"current"`
+	List        float64 `json:"list" yaml:"list"`
+	Currency    string  `json:"currency" yaml:"currency"`
+	DiscountPct float64 `json:"discount_pct" yaml:"discount_pct"`
+}
+
+// Coupon is a clippable coupon shown next to the price, Present is false when there is none.
+// Only one of AmountOff or PercentOff is set, depending on the kind of discount
+type Coupon struct {
+	Present    bool    `json:"present" yaml:"present"`
+	Text       string  `json:"text,omitempty" yaml:"text,omitempty"`
+	AmountOff  float64 `json:"amount_off,omitempty" yaml:"amount_off,omitempty"`
+	PercentOff float64 `json:"percent_off,omitempty" yaml:"percent_off,omitempty"`
+}
+
+// ShippingInfo is the delivery offer for the buy box, fields stay empty when the page has no delivery block
+type ShippingInfo struct {
+	// Cost as shown, "0" for free delivery
+	Cost              string  `json:"cost,omitempty" yaml:"cost,omitempty"`
+	CostValue         float64 `json:"cost_value" yaml:"cost_value"`
+	EstimatedDelivery string  `json:"estimated_delivery,omitempty" yaml:"estimated_delivery,omitempty"`
+	Prime             bool    `json:"prime" yaml:"prime"`
+}
+
+// SellerInfo is who sells and ships the buy box offer, fields stay empty when
+// there's no buy box, e.g. for out of stock products
+type SellerInfo struct {
+	SoldBy    string `json:"sold_by,omitempty" yaml:"sold_by,omitempty"`
+	ShipsFrom string `json:"ships_from,omitempty" yaml:"ships_from,omitempty"`
+	// URL of a third-party seller's storefront
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// Variant is one dimension value of a sibling ASIN, e.g. Color "Midnight Black"
+type Variant struct {
+	ASIN      string `json:"asin" yaml:"asin"`
+	Dimension string `json:"dimension" yaml:"dimension"`
+	Value     string `json:"value" yaml:"value"`
+}
+
+// RankEntry is a product's Best Sellers Rank within one category
+type RankEntry struct {
+	Category string `json:"category" yaml:"category"`
+	Rank     int    `json:"rank" yaml:"rank"`
+}
+
+// Canonical product page URL for an ASIN
+func productPageURL(productID string, domain string) string {
+	return fmt.Sprintf("https://www.%s/dp/%s", domain, productID)
+}
+
+// Fetch and parse a product page, re-fetching once when the price is missing and opts asks for it.
+// The result doesn't depend on any other option, so it's what the in-memory cache keeps
+func fetchProductPage(productID string, domain string, opts Options) (Product, error) {
+	url := productPageURL(productID, domain)
+	
+	userAgent := pickUserAgent(opts)
+	html, err := fetchHTMLAs(context.Background(), url, opts, userAgent)
+	if errors.Is(err, errPageNotFound) {
+		return Product{ASIN: productID, URL: url}, fmt.Errorf("%w: %s", ErrProductNotFound, productID)
+	}
+	if err != nil {
+		// Keep the identifiers so batch output can still be tied back to its input
+		return Product{ASIN: productID, URL: url}, err
+	}
+
+	product := parseProductDetails(html, domain)
+	product.ASIN, product.URL = productID, url
+
+	// A title without a price is usually a partially loaded page, one more fetch often fixes it.
+	// It's one of the retries, so a captcha on the re-fetch only gets the ones left
+	if opts.RetryOnEmptyPrice && opts.Retries > 0 && product.Title != "" && product.Price == "" {
+		slog.Info("Price missing, re-fetching product page once", "asin", productID)
+		// Bypass the cache, it would just hand back the same page
+		retryOpts := opts
+		retryOpts.CacheDir = ""
+		retryOpts.Retries--
+		html, err = fetchHTMLAs(context.Background(), url, retryOpts, freshUserAgent(opts, userAgent))
+		if err != nil {
+			slog.Warn("Re-fetch failed, keeping first result", "asin", productID, "error", err)
+		} else {
+			writeCache(url, html, opts)
+			product = parseProductDetails(html, domain)
+			product.ASIN, product.URL = productID, url
+		}
+	}
+
+	return product, nil
+}
+
+// Apply the options that don't change what's fetched: the related ASIN cap and the price
+// conversion. Returns ErrEmptyProduct for pages without a title, price or rating
+func finishProduct(product Product, opts Options) (Product, error) {
+	product.RelatedASINs = limitRelatedASINs(product.RelatedASINs, opts.MaxRelated)
+
+	// A missing rate shouldn't cost the rest of the product
+	if opts.ConvertTo != "" && opts.Rates != nil {
+		if err := ConvertPrice(&product, opts.ConvertTo, opts.Rates); err != nil {
+			slog.Warn("Couldn't convert price", "asin", product.ASIN, "to", opts.ConvertTo, "error", err)
+		}
+	}
+
+	if isEmptyProduct(product) {
+		return product, fmt.Errorf("%w: %s", ErrEmptyProduct, product.ASIN)
+	}
+
+	return product, nil
+}
+
+// Interstitials and ad redirects parse without error but carry no product
+func isEmptyProduct(product Product) bool {
+	return product.Title == "" && product.Price == "" && product.Rating == 0
+}
+
+// ParseProduct parses product details from the HTML of a product page, returning
+// ErrCaptcha when the page is a robot check rather than a product. The domain is
+// the marketplace the page came from (e.g. "amazon.de" or "de"), empty means amazon.com.
+func ParseProduct(html string, domain string) (Product, error) {
+	if isCaptchaPage(html) {
+		return Product{}, ErrCaptcha
+	}
+	if domain == "" {
+		domain = "amazon.com"
+	}
+	domain, err := RegionDomain(domain)
+	if err != nil {
+		return Product{}, err
+	}
+	product := parseProductDetails(html, domain)
+	product.RelatedASINs = limitRelatedASINs(product.RelatedASINs, 0)
+	return product, nil
+}
+
+// ParseProductHTML is ParseProduct for a page whose ASIN is known, filling in
+// the ASIN and canonical URL the page itself doesn't reliably carry. An empty
+// asin keeps the one ParseProduct read from the page, if any
+func ParseProductHTML(html string, asin string, domain string) (Product, error) {
+	product, err := ParseProduct(html, domain)
+	if err != nil || asin == "" {
+		return product, err
+	}
+	if domain == "" {
+		domain = "amazon.com"
+	}
+	// Already validated by ParseProduct
+	domain, _ = RegionDomain(domain)
+	product.ASIN, product.URL = asin, productPageURL(asin, domain)
+	return product, nil
+}
+
+// Parse product details from the HTML of a product page
+func parseProductDetails(html string, domain string) Product {
+	product := Product{}
+	doc := soup.HTMLParse(html)
+
+	// Extract product title (multiple possible selectors)
+	titleSelectors := [][]string{
+		{"span", "id", "productTitle"},
+		{"h1", "id", "title"},
+		{"h1", "class", "a-spacing-none"},
+	}
+	for _, selector := range titleSelectors {
+		titleElem := doc.Find(selector...)
+		if titleElem.Error == nil {
+			title := cleanText(titleElem.FullText())
+			if title != "" {
+				product.Title = title
+				break
+			}
+		}
+	}
+	
+	// Extract brand from the byline link, falling back to the product information table
+	bylineElem := doc.Find("a", "id", "bylineInfo")
+	if bylineElem.Error == nil {
+		product.Brand = normalizeBrand(bylineElem.FullText())
+	}
+	if product.Brand == "" {
+		product.Brand = normalizeBrand(findDetailValue(doc, "Brand"))
+	}
+	product.Manufacturer = normalizeBrand(findDetailValue(doc, "Manufacturer"))
+
+	// Extract product price (try multiple selectors as Amazon's structure changes)
+	priceSelectors := [][]string{
+		// Selector type, selector
+		{"class", "a-price"},
+		{"class", "a-price a-text-price"},
+		{"id", "priceblock_ourprice"},
+		{"id", "priceblock_dealprice"},
+		{"id", "price"},
+		{"class", "a-color-price"},
+	}
+
+	for _, selectorPair := range priceSelectors {
+		selectorType, selector := selectorPair[0], selectorPair[1]
+		var priceElem soup.Root
+		
+		if selectorType == "id" {
+			priceElem = doc.Find("span", "id", selector)
+		} else {
+			priceElem = doc.Find("span", "class", selector)
+		}
+		
+		if priceElem.Error == nil {
+			// Try to get the price from the found element
+			priceText := cleanText(priceElem.Text())
+			if priceText != "" {
+				product.Price = priceText
+				break
+			}
+			
+			// If no text directly, try to find the offscreen price
+			offscreenPrice := priceElem.Find("span", "class", "a-offscreen")
+			if offscreenPrice.Error == nil {
+				priceText = cleanText(offscreenPrice.Text())
+				if priceText != "" {
+					product.Price = priceText
+					break
+				}
+			}
+		}
+	}
+	
+	// If price is still empty, try a more general approach
+	if product.Price == "" {
+		allPriceSpans := doc.FindAll("span", "class", "a-offscreen")
+		for _, span := range allPriceSpans {
+			text := cleanText(span.Text())
+			// Make sure it starts with a currency symbol
+			if text != "" && strings.ContainsAny(text[:1], "$£€¥") {
+				product.Price = text
+				break
+			}
+		}
+	}
+
+	// Listings with variations in several prices show a range, whose first price the selectors above pick alone
+	rangeElem := doc.Find("span", "class", "a-price-range")
+	if rangeElem.Error == nil {
+		var bounds []string
+		for _, offscreen := range rangeElem.FindAll("span", "class", "a-offscreen") {
+			if text := cleanText(offscreen.FullText()); text != "" {
+				bounds = append(bounds, text)
+			}
+		}
+		if len(bounds) > 1 {
+			product.Price = bounds[0] + " - " + bounds[len(bounds)-1]
+		}
+	}
+
+	// Build the structured price, reading the struck-through list price when discounted.
+	// A range such as "$19.99 - $29.99" is kept as is in Price and priced at its minimum
+	priceMin, priceMax, isRange := parsePriceRange(product.Price)
+	if isRange {
+		product.PriceMin, product.PriceMax = priceMin, priceMax
+		product.Pricing = &Pricing{Current: priceMin, List: priceMin, Currency: detectCurrency(product.Price, domain)}
+	} else if current, ok := parsePriceAmount(product.Price); ok {
+		pricing := &Pricing{Current: current, List: current, Currency: detectCurrency(product.Price, domain)}
+		for _, class := range []string{"basisPrice", "a-text-price"} {
+			for _, elem := range doc.FindAll("span", "class", class) {
+				offscreen := elem.Find("span", "class", "a-offscreen")
+				if offscreen.Error != nil {
+					continue
+				}
+				// Unit prices also use a-text-price, a list price is always above the current one
+				if list, ok := parsePriceAmount(offscreen.FullText()); ok && list > current {
+					pricing.List = list
+					break
+				}
+			}
+			if pricing.List > current {
+				break
+			}
+		}
+		if pricing.List > current {
+			pricing.DiscountPct = math.Round((pricing.List-current)/pricing.List*10000) / 100
+		}
+		product.Pricing = pricing
+	}
+
+	// Extract clippable coupons such as "Save 15%", "$5.00 off" or "Apply 10% coupon"
+	if labelElem := doc.Find("span", "class", "couponLabelText"); labelElem.Error == nil {
+		product.Coupon.Text = cleanText(labelElem.FullText())
+	}
+	// The sentence naming the coupon, a "." only ends one when followed by a space so prices stay whole
+	couponPattern := regexp.MustCompile(`(?i)(?:[^.|]|\.\S)*\bcoupon\b`)
+	for _, id := range []string{"couponBadge", "promoPriceBlockMessage_feature_div", "vpcButton"} {
+		if product.Coupon.Text != "" {
+			break
+		}
+		couponElem := doc.Find("", "id", id)
+		if couponElem.Error != nil {
+			continue
+		}
+		couponText := cleanText(couponElem.FullText())
+		product.Coupon.Text = strings.TrimSpace(couponPattern.FindString(couponText))
+	}
+	if product.Coupon.Text != "" {
+		product.Coupon.Present = true
+		if match := regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*%`).FindStringSubmatch(product.Coupon.Text); match != nil {
+			product.Coupon.PercentOff, _ = strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+		} else if amount := regexp.MustCompile(`[$£€¥₹]\s?\d[\d.,]*|\d[\d.,]*\s?[$£€¥₹]`).FindString(product.Coupon.Text); amount != "" {
+			product.Coupon.AmountOff, _ = parsePriceAmount(amount)
+		}
+	}
+
+	// Detect deal badges such as "Limited time deal" or "Lightning Deal"
+	dealPattern := regexp.MustCompile(`(?i)\bdeal\b`)
+	var dealElems []soup.Root
+	if dealElem := doc.Find("div", "id", "dealBadge_feature_div"); dealElem.Error == nil {
+		dealElems = append(dealElems, dealElem)
+	}
+	dealElems = append(dealElems, doc.FindAll("span", "class", "a-badge-text")...)
+	for _, dealElem := range dealElems {
+		dealText := cleanText(dealElem.FullText())
+		if dealPattern.MatchString(dealText) {
+			product.Deal = dealText
+			break
+		}
+	}
+
+	// Extract product rating (try multiple selectors)
+	ratingSelectors := [][]string{
+		{"id", "acrPopover"},
+		{"class", "a-icon-star"},
+		{"class", "a-star-medium-4"},
+	}
+	
+	for _, selectorPair := range ratingSelectors {
+		selectorType, selector := selectorPair[0], selectorPair[1]
+		var ratingElem soup.Root
+		
+		if selectorType == "id" {
+			ratingElem = doc.Find("span", "id", selector)
+			if ratingElem.Error == nil {
+				// Try to extract from title attribute
+				ratingStr, exists := ratingElem.Attrs()["title"]
+				if exists && strings.Contains(ratingStr, "out of 5 stars") {
+					parts := strings.Split(ratingStr, " ")
+					if len(parts) > 0 {
+						product.Rating, _ = strconv.ParseFloat(parts[0], 64)
+						break
+					}
+				}
+			}
+		} else {
+			ratingElems := doc.FindAll("i", "class", selector)
+			if len(ratingElems) > 0 {
+				ratingText := ratingElems[0].Text()
+				if strings.Contains(ratingText, "out of 5 stars") {
+					parts := strings.Split(ratingText, " ")
+					if len(parts) > 0 {
+						product.Rating, _ = strconv.ParseFloat(parts[0], 64)
+						break
+					}
+				}
+				
+				// Try another method - from the class name
+				for _, elem := range ratingElems {
+					classes, exists := elem.Attrs()["class"]
+					if exists && strings.Contains(classes, "a-star-") {
+						re := regexp.MustCompile(`a-star-(\d)(?:[-.](\d))?`)
+						matches := re.FindStringSubmatch(classes)
+						if len(matches) >= 2 {
+							major, _ := strconv.ParseFloat(matches[1], 64)
+							minor := 0.0
+							if len(matches) >= 3 && matches[2] != "" {
+								minor, _ = strconv.ParseFloat("0."+matches[2], 64)
+							}
+							product.Rating = major + minor
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Extract the star rating histogram as star -> percentage of ratings, nil when it isn't rendered
+	histogramRows := doc.FindAll("tr", "class", "a-histogram-row")
+	if len(histogramRows) == 0 {
+		for _, id := range []string{"histogramTable", "cm_cr_dp_d_rating_histogram"} {
+			histogramElem := doc.Find("", "id", id)
+			if histogramElem.Error != nil {
+				continue
+			}
+			histogramRows = histogramElem.FindAll("tr")
+			if len(histogramRows) == 0 {
+				histogramRows = histogramElem.FindAll("li")
+			}
+			break
+		}
+	}
+	if len(histogramRows) == 0 {
+		histogramElem := doc.Find("div", "class", "cr-widget-Histogram")
+		if histogramElem.Error == nil {
+			histogramRows = histogramElem.FindAll("li")
+		}
+	}
+
+	histogramPattern := regexp.MustCompile(`([1-5])\D+?(\d{1,3}(?:[.,]\d+)?)\s*%`)
+	for _, row := range histogramRows {
+		match := histogramPattern.FindStringSubmatch(row.FullText())
+		if len(match) > 2 {
+			star, _ := strconv.Atoi(match[1])
+			percent, err := strconv.ParseFloat(strings.Replace(match[2], ",", ".", 1), 64)
+			if err != nil || percent > 100 {
+				continue
+			}
+			if product.RatingBreakdown == nil {
+				product.RatingBreakdown = make(map[int]float64)
+			}
+			product.RatingBreakdown[star] = percent
+		}
+	}
+
+	// Extract the number of answered questions, e.g. "1,234 answered questions"
+	questionsElem := doc.Find("a", "id", "askATFLink")
+	if questionsElem.Error == nil {
+		product.QuestionCount = parseQuestionCount(questionsElem.FullText())
+	}
+
+	// Extract Best Sellers Rank, from the additional information table or the detail bullets list
+	var rankText string
+	for _, label := range bestSellersRankLabels {
+		if rankText = findDetailValue(doc, label); rankText != "" {
+			break
+		}
+	}
+	if rankText == "" {
+		for _, id := range []string{"detailBulletsWrapper_feature_div", "detailBullets_feature_div"} {
+			bulletsElem := doc.Find("div", "id", id)
+			if bulletsElem.Error != nil {
+				continue
+			}
+			for _, bullet := range bulletsElem.FindAll("li") {
+				if bulletText := bullet.FullText(); containsAny(bulletText, bestSellersRankLabels) {
+					rankText = bulletText
+					break
+				}
+			}
+			if rankText != "" {
+				break
+			}
+		}
+	}
+	product.BestSellersRank = parseBestSellersRank(rankText)
+
+	// Extract the breadcrumb category path, from top-level department to leaf
+	breadcrumbsElem := doc.Find("div", "id", "wayfinding-breadcrumbs_feature_div")
+	if breadcrumbsElem.Error == nil {
+		for _, link := range breadcrumbsElem.FindAll("a") {
+			category := strings.TrimSpace(strings.Trim(cleanText(link.FullText()), "›>"))
+			if category != "" {
+				product.Categories = append(product.Categories, category)
+			}
+		}
+	}
+
+	// Without breadcrumbs the department link in the navigation bar is the best we have
+	if len(product.Categories) == 0 {
+		subnavElem := doc.Find("div", "id", "nav-subnav")
+		if subnavElem.Error == nil {
+			departmentElem := subnavElem.Find("a", "class", "nav-b")
+			if departmentElem.Error == nil {
+				department := cleanText(departmentElem.FullText())
+				if department != "" {
+					product.Categories = []string{department}
+				}
+			}
+		}
+	}
+
+	// Extract product description (try multiple locations)
+	descriptionIDs := []string{
+		"productDescription",
+		"dpx-product-description_feature_div",
+		"feature-bullets",
+		"dpx-feature-bullets_feature_div",
+		"bookDescription_feature_div",
+		"aplus",
+	}
+	
+	for _, id := range descriptionIDs {
+		descElem := doc.Find("div", "id", id)
+		if descElem.Error == nil {
+			desc := cleanText(visibleText(descElem))
+			if desc != "" {
+				product.Description = desc
+				break
+			}
+		}
+	}
+	
+	// If we still don't have a description, look for bullet points
+	if product.Description == "" {
+		bulletPoints := doc.FindAll("li", "class", "a-spacing-mini")
+		var bulletTexts []string
+		
+		for _, bullet := range bulletPoints {
+			bulletText := cleanText(bullet.FullText())
+			if bulletText != "" {
+				bulletTexts = append(bulletTexts, bulletText)
+			}
+		}
+		
+		if len(bulletTexts) > 0 {
+			product.Description = strings.Join(bulletTexts, " • ")
+		}
+	}
+
+	// Extract every key/value row from the technical details and additional information
+	// tables and the detail bullets list
+	details := make(map[string]string)
+	for _, id := range detailTableIDs {
+		tableElem := doc.Find("table", "id", id)
+		if tableElem.Error != nil {
+			continue
+		}
+		for _, row := range tableElem.FindAll("tr") {
+			keyElem, valueElem := row.Find("th"), row.Find("td")
+			if keyElem.Error == nil && valueElem.Error == nil {
+				addDetail(details, keyElem.FullText(), valueElem.FullText())
+			}
+		}
+	}
+	detailBulletsElem := doc.Find("div", "id", "detailBullets_feature_div")
+	if detailBulletsElem.Error == nil {
+		for _, bullet := range detailBulletsElem.FindAll("li") {
+			if key, value, found := strings.Cut(bullet.FullText(), ":"); found {
+				addDetail(details, key, value)
+			}
+		}
+	}
+	if len(details) > 0 {
+		product.Details = details
+	}
+
+	// Promote the first available date and shipping weight to typed fields
+	for _, label := range dateFirstAvailableLabels {
+		if value := findDetailValue(doc, label); value != "" {
+			product.DateFirstAvailable = parseLocalizedDate(value)
+			break
+		}
+	}
+	for _, label := range shippingWeightLabels {
+		if value := findDetailValue(doc, label); value != "" {
+			product.ShippingWeight = strings.TrimSpace(strings.TrimSuffix(value, "(View shipping rates and policies)"))
+			break
+		}
+	}
+
+	// Extract availability / stock status
+	availabilityElem := doc.Find("div", "id", "availability")
+	if availabilityElem.Error == nil {
+		product.Availability = cleanText(availabilityElem.FullText())
+		product.InStock, product.StockCount = parseAvailability(product.Availability)
+	}
+
+	// Extract seller and shipper from the tabular buybox
+	buyboxElem := doc.Find("div", "id", "tabular-buybox")
+	if buyboxElem.Error == nil {
+		for _, textElem := range buyboxElem.FindAll("div", "class", "tabular-buybox-text") {
+			value := cleanText(textElem.FullText())
+			switch textElem.Attrs()["tabular-attribute-name"] {
+			case "Ships from", "Dispatches from":
+				product.Seller.ShipsFrom = value
+			case "Sold by":
+				product.Seller.SoldBy = value
+			}
+		}
+	}
+
+	// The newer offer display marks the rows with feature names instead
+	for _, featureElem := range doc.FindAll("div", "class", "offer-display-feature-text") {
+		value := cleanText(featureElem.FullText())
+		switch featureElem.Attrs()["offer-display-feature-name"] {
+		case "desktop-fulfiller-info":
+			if product.Seller.ShipsFrom == "" {
+				product.Seller.ShipsFrom = value
+			}
+		case "desktop-merchant-info":
+			if product.Seller.SoldBy == "" {
+				product.Seller.SoldBy = value
+			}
+		}
+	}
+
+	// Older layouts describe both in a sentence, e.g. "Ships from and sold by Amazon.com."
+	// or, for FBA listings, "Sold by XYZ and Fulfilled by Amazon."
+	merchantElem := doc.Find("div", "id", "merchant-info")
+	if merchantElem.Error == nil && (product.Seller.SoldBy == "" || product.Seller.ShipsFrom == "") {
+		merchantText := cleanText(merchantElem.FullText())
+		for _, sentence := range regexp.MustCompile(`\.(?:\s+|$)`).Split(merchantText, -1) {
+			if match := regexp.MustCompile(`(?i)(?:ships|dispatched|dispatches) from and sold by (.+)`).FindStringSubmatch(sentence); match != nil {
+				product.Seller.SoldBy, product.Seller.ShipsFrom = match[1], match[1]
+				continue
+			}
+			if match := regexp.MustCompile(`(?i)sold by (.+?)(?: and (?:fulfilled|shipped|dispatched) by (.+))?$`).FindStringSubmatch(sentence); match != nil {
+				product.Seller.SoldBy = match[1]
+				if match[2] != "" {
+					product.Seller.ShipsFrom = match[2]
+				}
+				continue
+			}
+			if match := regexp.MustCompile(`(?i)(?:ships|dispatches) from (.+)`).FindStringSubmatch(sentence); match != nil {
+				product.Seller.ShipsFrom = match[1]
+			}
+		}
+	}
+
+	// Third-party sellers link to their storefront
+	sellerLink := doc.Find("a", "id", "sellerProfileTriggerId")
+	if sellerLink.Error == nil {
+		href := sellerLink.Attrs()["href"]
+		if strings.HasPrefix(href, "/") {
+			href = fmt.Sprintf("https://www.%s%s", domain, href)
+		}
+		product.Seller.URL = href
+		if product.Seller.SoldBy == "" {
+			product.Seller.SoldBy = cleanText(sellerLink.FullText())
+		}
+	}
+
+	// Extract shipping cost from the delivery block. The cost depends on the
+	// delivery location Amazon assumes for the request (usually derived from
+	// the IP address), so it may differ from what a signed-in shopper sees.
+	deliveryPattern := regexp.MustCompile(`(?i)(FREE|[$£€¥]\s?\d[\d.,]*)\s+(?:delivery|shipping)`)
+	for _, id := range []string{"mir-layout-DELIVERY_BLOCK", "deliveryBlockMessage"} {
+		deliveryElem := doc.Find("div", "id", id)
+		if deliveryElem.Error != nil {
+			continue
+		}
+
+		// Amazon tags the primary delivery message with its cost
+		for _, span := range deliveryElem.FindAll("span") {
+			cost := strings.TrimSpace(span.Attrs()["data-csa-c-delivery-price"])
+			if cost != "" {
+				product.Shipping.Cost = cost
+				break
+			}
+		}
+
+		// Otherwise look for the cost in the message text
+		if product.Shipping.Cost == "" {
+			match := deliveryPattern.FindStringSubmatch(deliveryElem.FullText())
+			if len(match) > 1 {
+				product.Shipping.Cost = match[1]
+			}
+		}
+
+		if product.Shipping.Cost != "" {
+			if strings.EqualFold(product.Shipping.Cost, "FREE") {
+				product.Shipping.Cost = "0"
+			} else {
+				product.Shipping.CostValue, _ = parsePriceAmount(product.Shipping.Cost)
+			}
+			break
+		}
+	}
+
+	// Extract the delivery estimate, tagged on the primary delivery message or
+	// else its first sentence, e.g. "FREE delivery Tuesday, October 21"
+	for _, id := range []string{"mir-layout-DELIVERY_BLOCK", "deliveryBlockMessage"} {
+		deliveryElem := doc.Find("div", "id", id)
+		if deliveryElem.Error != nil {
+			continue
+		}
+
+		for _, span := range deliveryElem.FindAll("span") {
+			if estimate := strings.TrimSpace(span.Attrs()["data-csa-c-delivery-time"]); estimate != "" {
+				product.Shipping.EstimatedDelivery = estimate
+				break
+			}
+		}
+		if product.Shipping.EstimatedDelivery == "" {
+			message := cleanText(deliveryElem.FullText())
+			product.Shipping.EstimatedDelivery = strings.TrimSpace(strings.SplitN(message, ". ", 2)[0])
+		}
+		if product.Shipping.EstimatedDelivery != "" {
+			break
+		}
+	}
+
+	// Detect Prime eligibility from the Prime badge or the Prime icon next to the price
+	// or in the delivery block, the icon elsewhere on the page belongs to other products
+	product.Shipping.Prime = doc.Find("", "id", "primeBadge").Error == nil
+	for _, id := range []string{"priceBadging_feature_div", "apex_desktop", "mir-layout-DELIVERY_BLOCK", "deliveryBlockMessage"} {
+		if product.Shipping.Prime {
+			break
+		}
+		badgeArea := doc.Find("div", "id", id)
+		if badgeArea.Error == nil && badgeArea.Find("i", "class", "a-icon-prime").Error == nil {
+			product.Shipping.Prime = true
+			break
+		}
+	}
+
+	// Detect exclusivity badges such as "Amazon Exclusive" or "Limited Release"
+	exclusivePattern := regexp.MustCompile(`(?i)\b(amazon exclusive|only at amazon|limited release|limited edition|exclusive)\b`)
+	var badgeElems []soup.Root
+	for _, id := range []string{"acBadge_feature_div", "zeitgeistBadge_feature_div", "exclusiveBadge_feature_div"} {
+		if badgeElem := doc.Find("div", "id", id); badgeElem.Error == nil {
+			badgeElems = append(badgeElems, badgeElem)
+		}
+	}
+	badgeElems = append(badgeElems, doc.FindAll("span", "class", "a-badge-text")...)
+
+	for _, badgeElem := range badgeElems {
+		badgeText := cleanText(badgeElem.FullText())
+		if exclusivePattern.MatchString(badgeText) {
+			product.Exclusive = true
+			product.ExclusiveLabel = badgeText
+			break
+		}
+	}
+
+	// Extract product images, the main image first followed by the gallery thumbnails
+	var imageURLs []string
+	landingElem := doc.Find("img", "id", "landingImage")
+	if landingElem.Error == nil {
+		attrs := landingElem.Attrs()
+		mainImage := attrs["data-old-hires"]
+
+		// data-a-dynamic-image maps each available URL to its [width, height]
+		if mainImage == "" && attrs["data-a-dynamic-image"] != "" {
+			var dynamicImages map[string][]int
+			if json.Unmarshal([]byte(attrs["data-a-dynamic-image"]), &dynamicImages) == nil {
+				largest := 0
+				for imageURL, size := range dynamicImages {
+					if len(size) == 2 && size[0]*size[1] > largest {
+						largest = size[0] * size[1]
+						mainImage = imageURL
+					}
+				}
+			}
+		}
+
+		if mainImage == "" && !strings.HasPrefix(attrs["src"], "data:") {
+			mainImage = attrs["src"]
+		}
+		if mainImage != "" {
+			imageURLs = append(imageURLs, mainImage)
+		}
+	}
+
+	altImagesElem := doc.Find("div", "id", "altImages")
+	if altImagesElem.Error == nil {
+		for _, imgElem := range altImagesElem.FindAll("img") {
+			// Skip sprites and video overlays, product images live under /images/I/
+			src := imgElem.Attrs()["src"]
+			if strings.Contains(src, "/images/I/") {
+				imageURLs = append(imageURLs, src)
+			}
+		}
+	}
+
+	seenImages := make(map[string]bool)
+	for _, imageURL := range imageURLs {
+		imageURL = fullSizeImageURL(imageURL)
+		if !seenImages[imageURL] {
+			seenImages[imageURL] = true
+			product.Images = append(product.Images, imageURL)
+		}
+	}
+	if len(product.Images) > 0 {
+		product.MainImage = product.Images[0]
+	}
+
+	// Variations share the parent ASIN named in the twister data
+	if match := regexp.MustCompile(`"parentAsin"\s*:\s*"([A-Z0-9]{10})"`).FindStringSubmatch(html); match != nil {
+		product.ParentASIN = match[1]
+	}
+	product.Variants = parseVariants(html)
+	product.SelectedVariation = parseSelectedVariation(doc)
+	product.RelatedASINs = parseRelatedASINs(doc)
+
+	// Saved pages come without their URL, but the add-to-cart form names the ASIN
+	if asin := pageASIN(doc); asin != "" {
+		product.ASIN, product.URL = asin, productPageURL(asin, domain)
+	}
+
+	return product
+}
+
+// Related ASINs kept per product when Options.MaxRelated is 0
+const defaultMaxRelated = 50
+
+// Ids of the "Frequently bought together" and "Products related to this item" carousels,
+// numbered variants like sims-consolidated-2_feature_div match by prefix
+var relatedCarouselPrefixes = []string{"sims-consolidated", "sims-fbt", "sp_detail", "similarities_feature_div"}
+
+// ASIN in a product link such as /Some-Name/dp/B08N5WRWNW/ref=...
+var dpLinkPattern = regexp.MustCompile(`/dp/([A-Z0-9]{10})`)
+
+// The ASIN of the product a page is for, from the hidden input of its add-to-cart form
+func pageASIN(doc soup.Root) string {
+	if elem := doc.Find("input", "id", "ASIN"); elem.Error == nil {
+		if asin := strings.TrimSpace(elem.Attrs()["value"]); regexp.MustCompile(`^[A-Z0-9]{10}$`).MatchString(asin) {
+			return asin
+		}
+	}
+	return ""
+}
+
+// Parse the ASINs of the cards in the related product carousels in page order,
+// leaving out the product's own ASIN
+func parseRelatedASINs(doc soup.Root) []string {
+	var asins []string
+	seen := map[string]bool{pageASIN(doc): true}
+	for _, area := range doc.FindAll("div") {
+		if !isRelatedCarousel(area.Attrs()["id"]) {
+			continue
+		}
+		// Cards carry data-asin, links inside them point at /dp/<ASIN>
+		for _, card := range area.FindAll("") {
+			attrs := card.Attrs()
+			asin := strings.TrimSpace(attrs["data-asin"])
+			if asin == "" && card.NodeValue == "a" {
+				if match := dpLinkPattern.FindStringSubmatch(attrs["href"]); match != nil {
+					asin = match[1]
+				}
+			}
+			if len(asin) != 10 || seen[asin] {
+				continue
+			}
+			seen[asin] = true
+			asins = append(asins, asin)
+		}
+	}
+	return asins
+}
+
+// Keep at most max related ASINs, the default cap when max is 0 and no cap when negative
+func limitRelatedASINs(asins []string, max int) []string {
+	if max == 0 {
+		max = defaultMaxRelated
+	}
+	if max > 0 && len(asins) > max {
+		return asins[:max]
+	}
+	return asins
+}
+
+// Check whether an element id belongs to one of the related product carousels
+func isRelatedCarousel(id string) bool {
+	for _, prefix := range relatedCarouselPrefixes {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse the selected value of each variation dimension from the twister rows such as
+// #variation_color_name and #variation_size_name, nil when the listing has no variations
+func parseSelectedVariation(doc soup.Root) map[string]string {
+	var selected map[string]string
+	for _, row := range doc.FindAll("div") {
+		id := row.Attrs()["id"]
+		if !strings.HasPrefix(id, "variation_") {
+			continue
+		}
+
+		valueElem := row.Find("span", "class", "selection")
+		if valueElem.Error != nil {
+			continue
+		}
+		value := cleanDetailText(valueElem.FullText())
+
+		// The label reads "Color:", fall back to the id for rows without one
+		dimension := strings.TrimSuffix(strings.TrimPrefix(id, "variation_"), "_name")
+		if dimension != "" {
+			dimension = strings.ToUpper(dimension[:1]) + strings.ReplaceAll(dimension[1:], "_", " ")
+		}
+		if labelElem := row.Find("label", "class", "a-form-label"); labelElem.Error == nil {
+			if label := cleanDetailText(labelElem.FullText()); label != "" {
+				dimension = label
+			}
+		}
+
+		if value != "" {
+			if selected == nil {
+				selected = make(map[string]string)
+			}
+			selected[dimension] = value
+		}
+	}
+	return selected
+}
+
+// Parse the sibling ASINs from the twister data Amazon embeds in a script tag,
+// nil for single-variant products
+func parseVariants(html string) []Variant {
+	dimensionsMatch := regexp.MustCompile(`"dimensionsDisplay"\s*:\s*(\[[^\]]*\])`).FindStringSubmatch(html)
+	valuesMatch := regexp.MustCompile(`"dimensionValuesDisplayData"\s*:\s*(\{[^}]*\})`).FindStringSubmatch(html)
+	if dimensionsMatch == nil || valuesMatch == nil {
+		return nil
+	}
+
+	var dimensions []string
+	var values map[string][]string
+	if json.Unmarshal([]byte(dimensionsMatch[1]), &dimensions) != nil || json.Unmarshal([]byte(valuesMatch[1]), &values) != nil {
+		return nil
+	}
+
+	// Sort the ASINs so the output is stable across runs
+	asins := make([]string, 0, len(values))
+	for asin := range values {
+		asins = append(asins, asin)
+	}
+	sort.Strings(asins)
+
+	var variants []Variant
+	for _, asin := range asins {
+		for i, value := range values[asin] {
+			if i < len(dimensions) {
+				variants = append(variants, Variant{ASIN: asin, Dimension: dimensions[i], Value: value})
+			}
+		}
+	}
+	return variants
+}
+
+// Phrases used across the supported marketplaces for items that can't be bought (matched lowercase)
+var outOfStockPhrases = []string{
+	"currently unavailable", "out of stock", "temporarily unavailable",
+	"derzeit nicht verfügbar", "nicht auf lager",
+	"actuellement indisponible", "en rupture de stock",
+	"attualmente non disponibile", "non disponibile",
+	"no disponible", "agotado",
+	"現在在庫切れです", "在庫切れ",
+	"não disponível", "indisponível",
+	"niet beschikbaar", "niet op voorraad",
+	"inte tillgänglig", "slut i lager",
+}
+
+// Parse an availability message into an in-stock flag and, for "Only N left" messages, the count.
+// Any message that isn't a known out of stock phrase counts as in stock.
+func parseAvailability(text string) (bool, int) {
+	if text == "" {
+		return false, 0
+	}
+
+	lower := strings.ToLower(text)
+	for _, phrase := range outOfStockPhrases {
+		if strings.Contains(lower, phrase) {
+			return false, 0
+		}
+	}
+
+	stockCount := 0
+	match := regexp.MustCompile(`(?i)(?:only|nur noch|plus que|solo|quedan|apenas|nog maar|endast|残り)\s*(\d+)`).FindStringSubmatch(text)
+	if len(match) > 1 {
+		stockCount, _ = strconv.Atoi(match[1])
+	}
+	return true, stockCount
+}
+
+// Label of the Best Sellers Rank row on the supported marketplaces
+var bestSellersRankLabels = []string{
+	"Best Sellers Rank",
+	"Amazon Bestseller-Rang",
+	"Classement des meilleures ventes d'Amazon",
+	"Posizione nella classifica Bestseller di Amazon",
+	"Clasificación en los más vendidos de Amazon",
+	"Ranking dos mais vendidos",
+	"Plaats in bestsellerlijst",
+	"Rangordning bland bästsäljare",
+	"Amazon 売れ筋ランキング",
+}
+
+// Product information tables, "Technical Details" first then "Additional Information"
+var detailTableIDs = []string{
+	"productDetails_techSpec_section_1",
+	"productDetails_techSpec_section_2",
+	"productDetails_detailBullets_sections1",
+}
+
+// Localized labels of the "Date First Available" row in the product information
+var dateFirstAvailableLabels = []string{
+	"Date First Available",
+	"Im Angebot von Amazon.de seit",
+	"Date de mise en ligne sur Amazon.fr",
+	"Disponibile su Amazon.it a partire dal",
+	"Producto en Amazon.es desde",
+}
+
+// Localized labels of the shipping weight row in the product information
+var shippingWeightLabels = []string{
+	"Shipping Weight",
+	"Versandgewicht",
+	"Poids d'expédition",
+	"Peso di spedizione",
+	"Peso del envío",
+}
+
+// Check whether text contains any of the given phrases
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse rank lines such as "#1,234 in Electronics (See Top 100 in Electronics) #12 in Earbuds",
+// including the localized "Nr. 1.234 in ..." and "n°12 en ..." forms
+func parseBestSellersRank(text string) []RankEntry {
+	var ranks []RankEntry
+	text = regexp.MustCompile(`\([^)]*\)`).ReplaceAllString(text, " ")
+	rankPattern := regexp.MustCompile(`(?:#|Nr\.|n[°º.])\s?(\d[\d,.\x{00a0}\x{202f}]*)\s+(?:in|en)\s+`)
+
+	// Each category runs from the end of its rank marker to the start of the next one
+	matches := rankPattern.FindAllStringSubmatchIndex(text, -1)
+	for i, match := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+
+		number := regexp.MustCompile(`[,.\x{00a0}\x{202f}]`).ReplaceAllString(text[match[2]:match[3]], "")
+		rank, err := strconv.Atoi(number)
+		if err != nil {
+			continue
+		}
+		category := cleanText(text[match[1]:end])
+		ranks = append(ranks, RankEntry{Category: category, Rank: rank})
+	}
+	return ranks
+}
+
+// Parse the leading number of a Q&A link such as "1,234 answered questions" or "1.234 beantwortete Fragen",
+// 0 when there is none. The link reads "Ask a question" when nothing has been answered yet
+func parseQuestionCount(text string) int {
+	match := regexp.MustCompile(`\d[\d,.\x{00a0}\x{202f}]*`).FindString(text)
+	count, _ := strconv.Atoi(regexp.MustCompile(`[,.\x{00a0}\x{202f}]`).ReplaceAllString(match, ""))
+	return count
+}
+
+// Strip byline prefixes such as "Visit the Sony Store" or "Brand: Acme" down to the brand name
+func normalizeBrand(text string) string {
+	text = cleanText(text)
+
+	bylinePatterns := []string{
+		`^Visit the (.+?) Store$`,
+		`^Besuchen Sie den (.+?)-Store$`,
+		`^Visitez la boutique (.+)$`,
+		`^Visita la tienda de (.+)$`,
+		`^Visita lo Store di (.+)$`,
+		`^(?:Brand|Marke|Marque|Marca|Merk)\s*:\s*(.+)$`,
+	}
+	for _, pattern := range bylinePatterns {
+		match := regexp.MustCompile(pattern).FindStringSubmatch(text)
+		if len(match) > 1 {
+			return strings.TrimSpace(match[1])
+		}
+	}
+	return text
+}
+
+// Find the value of a row in the product information tables, or the detail bullets list, by its label
+func findDetailValue(doc soup.Root, label string) string {
+	for _, row := range doc.FindAll("tr") {
+		cells := row.FindAll("th")
+		cells = append(cells, row.FindAll("td")...)
+		if len(cells) < 2 {
+			continue
+		}
+		if strings.EqualFold(cleanDetailText(cells[0].FullText()), label) {
+			return cleanDetailText(cells[1].FullText())
+		}
+	}
+
+	// Detail bullets read "Manufacturer ‏ : ‎ Sony"
+	bulletsElem := doc.Find("div", "id", "detailBullets_feature_div")
+	if bulletsElem.Error == nil {
+		for _, bullet := range bulletsElem.FindAll("li") {
+			key, value, found := strings.Cut(bullet.FullText(), ":")
+			if found && strings.EqualFold(cleanDetailText(key), label) {
+				return cleanDetailText(value)
+			}
+		}
+	}
+	return ""
+}
+
+// Add a cleaned up key/value pair to the details map, keeping the first value seen for a key
+func addDetail(details map[string]string, key string, value string) {
+	key = cleanDetailText(key)
+	value = cleanDetailText(value)
+	if key == "" || value == "" {
+		return
+	}
+	if _, exists := details[key]; !exists {
+		details[key] = value
+	}
+}
+
+// Decode any HTML entities left in extracted text, e.g. double-escaped "&amp;amp;",
+// and collapse runs of whitespace into single spaces
+func cleanText(text string) string {
+	text = html.UnescapeString(text)
+	return strings.TrimSpace(regexp.MustCompile(`\s+`).ReplaceAllString(text, " "))
+}
+
+// Like FullText, but leaving out the contents of script and style elements,
+// which A+ content and some descriptions embed
+func visibleText(elem soup.Root) string {
+	var buf strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch {
+			case child.Type == html.TextNode:
+				buf.WriteString(child.Data)
+				buf.WriteString(" ")
+			case child.Type == html.ElementNode && child.Data != "script" && child.Data != "style" && child.Data != "noscript":
+				walk(child)
+			}
+		}
+	}
+	walk(elem.Pointer)
+	return buf.String()
+}
+
+// Like cleanText, also trimming the colons and invisible direction marks Amazon puts around detail labels and values
+func cleanDetailText(text string) string {
+	return strings.Trim(cleanText(text), " :\u00a0\u200e\u200f")
+}
+
+// Upgrade an Amazon image URL to full resolution by stripping size tokens like ._AC_US40_ or ._SY88
+func fullSizeImageURL(imageURL string) string {
+	return regexp.MustCompile(`\._[^/.]+\.`).ReplaceAllString(imageURL, ".")
+}
+
+// Detect the currency of a price from its symbol, falling back to the marketplace currency
+func detectCurrency(price string, domain string) string {
+	switch {
+	case strings.Contains(price, "£"):
+		return "GBP"
+	case strings.Contains(price, "€"):
+		return "EUR"
+	case strings.Contains(price, "₹"):
+		return "INR"
+	case strings.Contains(price, "R$"):
+		return "BRL"
+	case strings.Contains(price, "¥") || strings.Contains(price, "￥"):
+		return "JPY"
+	}
+	if m, ok := marketplaces[domain]; ok {
+		return m.currency
+	}
+	if strings.Contains(price, "$") {
+		return "USD"
+	}
+	return ""
+}
+
+// Parse a price range such as "$19.99 - $29.99" or "19,99 € – 29,99 €" into its bounds
+func parsePriceRange(text string) (float64, float64, bool) {
+	parts := regexp.MustCompile(`\s+[-–—]\s+|\s*[–—]\s*`).Split(strings.TrimSpace(text), -1)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	low, ok := parsePriceAmount(parts[0])
+	if !ok {
+		return 0, 0, false
+	}
+	high, ok := parsePriceAmount(parts[1])
+	if !ok || high < low {
+		return 0, 0, false
+	}
+	return low, high, true
+}
+
+// Parse the numeric amount from a price string such as "$1,234.56" or "1.234,56 €"
+func parsePriceAmount(text string) (float64, bool) {
+	number := regexp.MustCompile(`\d+(?:[.,\s]\d+)*`).FindString(text)
+	if number == "" {
+		return 0, false
+	}
+	number = strings.Join(strings.Fields(number), "")
+
+	// A final separator followed by exactly three digits groups thousands,
+	// anything else is the decimal separator
+	stripSeparators := strings.NewReplacer(".", "", ",", "")
+	lastSep := strings.LastIndexAny(number, ".,")
+	if lastSep >= 0 && len(number)-lastSep-1 != 3 {
+		number = stripSeparators.Replace(number[:lastSep]) + "." + number[lastSep+1:]
+	} else {
+		number = stripSeparators.Replace(number)
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,165 @@
+package scraper
+
+import (
+	"errors"
+	"testing"
+)
+
+// The robot check page Amazon serves in place of a product, trimmed to its markers
+const captchaPageFixture = `<html><head><title dir="ltr">Robot Check</title></head>
+<body><div class="a-container">
+  <form method="get" action="/errors/validateCaptcha" name="">
+    <h4>Type the characters you see in this image:</h4>
+    <input autocomplete="off" spellcheck="false" placeholder="Type characters" id="captchacharacters" name="field-keywords" type="text">
+  </form>
+</div></body></html>`
+
+func TestParseProductCaptcha(t *testing.T) {
+	if !isCaptchaPage(captchaPageFixture) {
+		t.Error("isCaptchaPage() didn't recognise the robot check page")
+	}
+	if _, err := ParseProduct(captchaPageFixture, ""); !errors.Is(err, ErrCaptcha) {
+		t.Errorf("ParseProduct() error = %v, want ErrCaptcha", err)
+	}
+
+	page := `<html><body><span id="productTitle">Echo Dot</span></body></html>`
+	if isCaptchaPage(page) {
+		t.Error("isCaptchaPage() flagged a product page")
+	}
+	if product, err := ParseProduct(page, ""); err != nil || product.Title != "Echo Dot" {
+		t.Errorf("ParseProduct() = %q, %v, want the title and no error", product.Title, err)
+	}
+}
+
+func TestParseProductDecodesEntities(t *testing.T) {
+	// Entities Amazon escaped twice reach the text still encoded once
+	page := `<html><body>
+<span id="productTitle">  Johnson &amp; Johnson Baby Shampoo  </span>
+<a id="bylineInfo" href="/stores/JohnsonJohnson">Visit the Johnson &amp;amp; Johnson Store</a>
+<div id="productDescription"><p>Gentle &amp;amp; tear-free, it&amp;#39;s a caf&amp;eacute; favourite</p></div>
+<div id="corePrice_feature_div"><span class="a-price"><span class="a-offscreen">
+  &amp;#36;9.99
+</span></span></div>
+<div id="detailBullets_feature_div"><ul><li><span>Manufacturer &rlm; : &lrm; </span><span>Johnson &amp;amp; Johnson</span></li></ul></div>
+</body></html>`
+	product, err := ParseProduct(page, "")
+	if err != nil {
+		t.Fatalf("ParseProduct() error: %v", err)
+	}
+	if product.Title != "Johnson & Johnson Baby Shampoo" {
+		t.Errorf("Title = %q", product.Title)
+	}
+	if product.Brand != "Johnson & Johnson" {
+		t.Errorf("Brand = %q", product.Brand)
+	}
+	if product.Description != "Gentle & tear-free, it's a café favourite" {
+		t.Errorf("Description = %q", product.Description)
+	}
+	if product.Price != "$9.99" {
+		t.Errorf("Price = %q", product.Price)
+	}
+	if product.Details["Manufacturer"] != "Johnson & Johnson" {
+		t.Errorf("Details = %q", product.Details)
+	}
+}
+
+func TestParsePriceRange(t *testing.T) {
+	tests := []struct {
+		text     string
+		min, max float64
+		ok       bool
+	}{
+		{"$19.99 - $29.99", 19.99, 29.99, true},
+		{"$1,299.00 – $1,499.00", 1299, 1499, true},
+		{"12,99 € - 24,99 €", 12.99, 24.99, true},
+		{"£5.00–£9.50", 5, 9.5, true},
+		{"$19.99", 0, 0, false},
+		{"$29.99 - $19.99", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		min, max, ok := parsePriceRange(tt.text)
+		if ok != tt.ok || min != tt.min || max != tt.max {
+			t.Errorf("parsePriceRange(%q) = %v, %v, %v, want %v, %v, %v", tt.text, min, max, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+}
+
+func TestParseProductDetailsPriceRange(t *testing.T) {
+	page := `<html><body>
+<span id="productTitle">T-Shirt</span>
+<div id="corePriceDisplay_desktop_feature_div">
+  <span class="a-price-range">
+    <span class="a-price"><span class="a-offscreen">$19.99</span><span aria-hidden="true">$19.99</span></span>
+    <span class="a-price-dash">-</span>
+    <span class="a-price"><span class="a-offscreen">$29.99</span><span aria-hidden="true">$29.99</span></span>
+  </span>
+</div>
+</body></html>`
+	product := parseProductDetails(page, "amazon.com")
+	if product.Price != "$19.99 - $29.99" {
+		t.Errorf("Price = %q, want the whole range", product.Price)
+	}
+	if product.PriceMin != 19.99 || product.PriceMax != 29.99 {
+		t.Errorf("PriceMin, PriceMax = %v, %v, want 19.99, 29.99", product.PriceMin, product.PriceMax)
+	}
+	if product.Pricing == nil || product.Pricing.Current != 19.99 || product.Pricing.Currency != "USD" {
+		t.Errorf("Pricing = %+v, want the minimum in USD", product.Pricing)
+	}
+}
+
+func TestParseProductDetailsCoupon(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		text    string
+		amount  float64
+		percent float64
+	}{
+		{
+			"amount",
+			`<div id="promoPriceBlockMessage_feature_div"><span>Limited offer. Save $5.00 with coupon. Terms apply</span></div>`,
+			"Save $5.00 with coupon", 5, 0,
+		},
+		{
+			"amount off",
+			`<div id="vpcButton"><label>Apply $5.00 off coupon</label></div>`,
+			"Apply $5.00 off coupon", 5, 0,
+		},
+		{
+			"percent",
+			`<div id="couponBadge"><span>Apply 15% coupon | Shop items</span></div>`,
+			"Apply 15% coupon", 0, 15,
+		},
+		{
+			"decimal percent",
+			`<div id="couponBadge"><span>Save 7,5% with coupon</span></div>`,
+			"Save 7,5% with coupon", 0, 7.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon := parseProductDetails(`<span id="productTitle">Widget</span>`+tt.page, "amazon.com").Coupon
+			if !coupon.Present || coupon.Text != tt.text || coupon.AmountOff != tt.amount || coupon.PercentOff != tt.percent {
+				t.Errorf("Coupon = %+v, want %q, %v off, %v%% off", coupon, tt.text, tt.amount, tt.percent)
+			}
+		})
+	}
+}
+
+func TestParseProductReadsPageASIN(t *testing.T) {
+	page := `<span id="productTitle">Echo Dot</span>
+<form id="addToCart"><input type="hidden" id="ASIN" name="ASIN" value="B08N5WRWNW"></form>`
+	product, err := ParseProductHTML(page, "", "de")
+	if err != nil {
+		t.Fatalf("ParseProductHTML() error: %v", err)
+	}
+	if product.ASIN != "B08N5WRWNW" || product.URL != "https://www.amazon.de/dp/B08N5WRWNW" {
+		t.Errorf("ASIN, URL = %q, %q, want them read from the page", product.ASIN, product.URL)
+	}
+
+	product, _ = ParseProductHTML(page, "B000000001", "")
+	if product.ASIN != "B000000001" || product.URL != "https://www.amazon.com/dp/B000000001" {
+		t.Errorf("ASIN, URL = %q, %q, want the given ASIN", product.ASIN, product.URL)
+	}
+}
@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"container/list"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// An entry of the in-memory product cache
+type productCacheEntry struct {
+	key     string
+	product Product
+	fetched time.Time
+}
+
+// LRU of products fetched by FetchProduct, shared by every call in the process.
+// The list is ordered from most to least recently used
+var (
+	productCacheMu    sync.Mutex
+	productCacheList  = list.New()
+	productCacheIndex = map[string]*list.Element{}
+)
+
+// Key products by ASIN and domain, the same ASIN differs between marketplaces, and by
+// whether a missing price was re-fetched. The other options are applied after the lookup
+func productCacheKey(productID string, domain string, opts Options) string {
+	key := domain + "/" + productID
+	if opts.RetryOnEmptyPrice {
+		key += "/retry"
+	}
+	return key
+}
+
+// Copy a product deeply, so callers changing its maps, slices or pointers can't change the cached one
+func cloneProduct(product Product) Product {
+	if product.Pricing != nil {
+		pricing := *product.Pricing
+		product.Pricing = &pricing
+	}
+	if product.ReviewSummary != nil {
+		summary := *product.ReviewSummary
+		product.ReviewSummary = &summary
+	}
+	product.RatingBreakdown = maps.Clone(product.RatingBreakdown)
+	product.Details = maps.Clone(product.Details)
+	product.SelectedVariation = maps.Clone(product.SelectedVariation)
+	product.BestSellersRank = slices.Clone(product.BestSellersRank)
+	product.Categories = slices.Clone(product.Categories)
+	product.Images = slices.Clone(product.Images)
+	product.Variants = slices.Clone(product.Variants)
+	product.RelatedASINs = slices.Clone(product.RelatedASINs)
+	product.Reviews = slices.Clone(product.Reviews)
+	for i := range product.Reviews {
+		product.Reviews[i].Images = slices.Clone(product.Reviews[i].Images)
+		product.Reviews[i].ImagePaths = slices.Clone(product.Reviews[i].ImagePaths)
+	}
+	return product
+}
+
+// Get a cached product that is still within opts.ProductCacheTTL
+func getCachedProduct(productID string, domain string, opts Options) (Product, bool) {
+	if opts.ProductCacheSize <= 0 {
+		return Product{}, false
+	}
+
+	productCacheMu.Lock()
+	defer productCacheMu.Unlock()
+
+	elem, ok := productCacheIndex[productCacheKey(productID, domain, opts)]
+	if !ok {
+		return Product{}, false
+	}
+	entry := elem.Value.(*productCacheEntry)
+	if opts.ProductCacheTTL > 0 && time.Since(entry.fetched) > opts.ProductCacheTTL {
+		productCacheList.Remove(elem)
+		delete(productCacheIndex, entry.key)
+		return Product{}, false
+	}
+
+	productCacheList.MoveToFront(elem)
+	slog.Debug("Serving product from memory", "asin", productID, "domain", domain)
+	return cloneProduct(entry.product), true
+}
+
+// Add a product to the cache, evicting the least recently used ones beyond opts.ProductCacheSize
+func putCachedProduct(productID string, domain string, product Product, opts Options) {
+	if opts.ProductCacheSize <= 0 {
+		return
+	}
+
+	productCacheMu.Lock()
+	defer productCacheMu.Unlock()
+
+	key := productCacheKey(productID, domain, opts)
+	if elem, ok := productCacheIndex[key]; ok {
+		productCacheList.Remove(elem)
+	}
+	productCacheIndex[key] = productCacheList.PushFront(&productCacheEntry{key: key, product: cloneProduct(product), fetched: time.Now()})
+
+	for productCacheList.Len() > opts.ProductCacheSize {
+		oldest := productCacheList.Back()
+		productCacheList.Remove(oldest)
+		delete(productCacheIndex, oldest.Value.(*productCacheEntry).key)
+	}
+}
@@ -0,0 +1,83 @@
+package scraper
+
+import "testing"
+
+func TestProductCacheAppliesOptionsAfterLookup(t *testing.T) {
+	opts := Options{ProductCacheSize: 10}
+	raw := Product{
+		ASIN:         "B000TEST01",
+		Title:        "Widget",
+		Price:        "10,00 €",
+		Pricing:      &Pricing{Current: 10, List: 10, Currency: "EUR"},
+		RelatedASINs: []string{"B000TEST02", "B000TEST03"},
+	}
+	putCachedProduct(raw.ASIN, "amazon.de", raw, opts)
+
+	rates := StaticRates{Base: "EUR", Rates: map[string]float64{"USD": 1.1, "GBP": 0.85}}
+	for _, tt := range []struct {
+		currency string
+		want     float64
+	}{{"USD", 11}, {"GBP", 8.5}} {
+		opts := opts
+		opts.ConvertTo, opts.Rates, opts.MaxRelated = tt.currency, rates, 1
+		cached, ok := getCachedProduct(raw.ASIN, "amazon.de", opts)
+		if !ok {
+			t.Fatal("product wasn't cached")
+		}
+		product, err := finishProduct(cached, opts)
+		if err != nil {
+			t.Fatalf("finishProduct() error: %v", err)
+		}
+		if product.ConvertedCurrency != tt.currency || product.PriceConverted != tt.want {
+			t.Errorf("converted to %s = %v %s, want %v", tt.currency, product.PriceConverted, product.ConvertedCurrency, tt.want)
+		}
+		if len(product.RelatedASINs) != 1 {
+			t.Errorf("RelatedASINs = %v, want the cap of 1 applied", product.RelatedASINs)
+		}
+	}
+
+	// A product fetched without the price retry isn't served to a caller asking for it
+	retry := opts
+	retry.RetryOnEmptyPrice = true
+	if _, ok := getCachedProduct(raw.ASIN, "amazon.de", retry); ok {
+		t.Error("product cached without RetryOnEmptyPrice was served with it")
+	}
+}
+
+func TestProductCacheReturnsCopies(t *testing.T) {
+	opts := Options{ProductCacheSize: 10}
+	putCachedProduct("B000TEST04", "amazon.com", Product{
+		Title:           "Widget",
+		Pricing:         &Pricing{Current: 5},
+		Details:         map[string]string{"Color": "Red"},
+		RatingBreakdown: map[int]float64{5: 80},
+		Images:          []string{"a.jpg"},
+	}, opts)
+
+	first, _ := getCachedProduct("B000TEST04", "amazon.com", opts)
+	first.Pricing.Current = 99
+	first.Details["Color"] = "Blue"
+	first.RatingBreakdown[5] = 0
+	first.Images[0] = "b.jpg"
+
+	second, _ := getCachedProduct("B000TEST04", "amazon.com", opts)
+	if second.Pricing.Current != 5 || second.Details["Color"] != "Red" || second.RatingBreakdown[5] != 80 || second.Images[0] != "a.jpg" {
+		t.Errorf("changes to a returned product leaked into the cache: %+v", second)
+	}
+}
+
+func TestProductCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	opts := Options{ProductCacheSize: 2}
+	for _, asin := range []string{"B000EVICT1", "B000EVICT2"} {
+		putCachedProduct(asin, "amazon.fr", Product{Title: asin}, opts)
+	}
+	getCachedProduct("B000EVICT1", "amazon.fr", opts)
+	putCachedProduct("B000EVICT3", "amazon.fr", Product{Title: "B000EVICT3"}, opts)
+
+	if _, ok := getCachedProduct("B000EVICT2", "amazon.fr", opts); ok {
+		t.Error("least recently used product wasn't evicted")
+	}
+	if _, ok := getCachedProduct("B000EVICT1", "amazon.fr", opts); !ok {
+		t.Error("recently used product was evicted")
+	}
+}
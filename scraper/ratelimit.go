@@ -0,0 +1,50 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Requests per second when Options.RPS is 0
+const defaultRPS = 0.5
+
+// Token bucket shared by every request in the process, so concurrent workers
+// and batch mode can't burst past the configured rate
+var (
+	limiterMu sync.Mutex
+	limiter   *rate.Limiter
+)
+
+// Wait until the shared limiter allows another request at opts.RPS, or ctx is done
+func waitForRateLimit(ctx context.Context, url string, opts Options) error {
+	rps := opts.RPS
+	if rps == 0 {
+		rps = defaultRPS
+	}
+	limit := rate.Limit(rps)
+	if rps < 0 {
+		limit = rate.Inf
+	}
+
+	limiterMu.Lock()
+	if limiter == nil {
+		limiter = rate.NewLimiter(limit, 1)
+	} else if limiter.Limit() != limit {
+		limiter.SetLimit(limit)
+	}
+	l := limiter
+	limiterMu.Unlock()
+
+	start := time.Now()
+	if err := l.Wait(ctx); err != nil {
+		return err
+	}
+	if delay := time.Since(start); delay > time.Millisecond {
+		slog.Debug("Rate limit delay", "url", url, "delay", delay)
+	}
+	return nil
+}
@@ -0,0 +1,559 @@
+package scraper
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anaskhan96/soup"
+	"golang.org/x/net/html"
+)
+
+// Review represents a product review, ImagePaths are where DownloadReviewImages saved Images
+type Review struct {
+	ID           string    `json:"id,omitempty" yaml:"id,omitempty"`
+	Author       string    `json:"author" yaml:"author"`
+	Date         string    `json:"date" yaml:"date"`
+	ParsedDate   time.Time `json:"parsed_date" yaml:"parsed_date"`
+	Country      string    `json:"country,omitempty" yaml:"country,omitempty"`
+	Rating       float64   `json:"rating" yaml:"rating"`
+	Title        string    `json:"title" yaml:"title"`
+	Content      string    `json:"content" yaml:"content"`
+	Verified     bool      `json:"verified" yaml:"verified"`
+	Variant      string    `json:"variant,omitempty" yaml:"variant,omitempty"`
+	HelpfulVotes int       `json:"helpful_votes" yaml:"helpful_votes"`
+	Images       []string  `json:"images,omitempty" yaml:"images,omitempty"`
+	ImagePaths   []string  `json:"image_paths,omitempty" yaml:"image_paths,omitempty"`
+}
+
+// ReviewRecord is a review tagged with the product it belongs to, so exported
+// reviews stay self-describing without the product around them
+type ReviewRecord struct {
+	ASIN   string `json:"asin" yaml:"asin"`
+	Domain string `json:"domain" yaml:"domain"`
+	Review `yaml:",inline"`
+}
+
+// ErrInvalidSort is returned for a review sort order other than the supported ones
+var ErrInvalidSort = errors.New("invalid review sort, use helpful or top, recent (both sorted by Amazon) or rating (sorted locally, as Amazon has no rating order)")
+
+// ReviewSummary is the rating totals from the header of a product's review pages
+type ReviewSummary struct {
+	TotalRatings int     `json:"total_ratings" yaml:"total_ratings"`
+	TotalReviews int     `json:"total_reviews" yaml:"total_reviews"`
+	Average      float64 `json:"average" yaml:"average"`
+}
+
+// Reviews Amazon shows per page unless Options.ReviewsPerPage asks for another size
+const defaultReviewsPerPage = 10
+
+// Default cap on review pages fetched for a single product
+const maxReviewPages = 10
+
+// Upper bound on Options.MaxPages, deeper pages are rarely served anyway
+const maxReviewPagesLimit = 100
+
+// Parse the reviews contained in a single review page
+func parseReviewPage(html string) []Review {
+	reviews := []Review{}
+	doc := soup.HTMLParse(html)
+	reviewElems := doc.FindAll("div", "data-hook", "review")
+
+	for _, reviewElem := range reviewElems {
+		review := Review{}
+
+		// Review IDs such as "R2XJ0KDBC1ZQ8V" are the element id
+		review.ID = reviewElem.Attrs()["id"]
+
+		// Extract review author
+		authorElem := reviewElem.Find("span", "class", "a-profile-name")
+		if authorElem.Error == nil {
+			review.Author = cleanText(authorElem.FullText())
+		}
+
+		// Extract review date
+		dateElem := reviewElem.Find("span", "data-hook", "review-date")
+		if dateElem.Error == nil {
+			review.Date = cleanText(dateElem.Text())
+			review.ParsedDate, review.Country = parseReviewDate(review.Date)
+		}
+
+		// Extract review rating from the icon's hidden text, e.g. "4.0 out of 5 stars" or "4,0 von 5 Sternen".
+		// Reviews from other countries use a different hook on the same markup
+		for _, hook := range []string{"review-star-rating", "cmps-review-star-rating"} {
+			ratingElem := reviewElem.Find("i", "data-hook", hook)
+			if ratingElem.Error != nil {
+				continue
+			}
+			if match := regexp.MustCompile(`^\s*(\d(?:[.,]\d)?)`).FindStringSubmatch(ratingElem.FullText()); match != nil {
+				review.Rating, _ = strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+			}
+			break
+		}
+
+		// Extract review title
+		titleElem := reviewElem.Find("a", "data-hook", "review-title")
+		if titleElem.Error == nil {
+			review.Title = cleanText(titleElem.FullText())
+		}
+
+		// Extract review content
+		contentElem := reviewElem.Find("span", "data-hook", "review-body")
+		if contentElem.Error == nil {
+			review.Content = cleanText(contentElem.FullText())
+		}
+
+		// Check if verified purchase
+		verifiedElem := reviewElem.Find("span", "data-hook", "avp-badge")
+		review.Verified = verifiedElem.Error == nil
+
+		// Extract the variation reviewed, e.g. "Size: Large | Color: Blue"
+		formatElem := reviewElem.Find("", "data-hook", "format-strip")
+		if formatElem.Error == nil {
+			review.Variant = parseFormatStrip(formatElem)
+		}
+
+		// Extract helpful votes, absent on reviews nobody has voted on yet
+		helpfulElem := reviewElem.Find("span", "data-hook", "helpful-vote-statement")
+		if helpfulElem.Error == nil {
+			review.HelpfulVotes = parseHelpfulVotes(helpfulElem.FullText())
+		}
+
+		// Extract photo review images at full size, nil when there are none
+		seen := map[string]bool{}
+		for _, imageElem := range reviewElem.FindAll("img", "data-hook", "review-image-tile") {
+			src := imageElem.Attrs()["src"]
+			if src == "" {
+				continue
+			}
+			src = fullSizeImageURL(src)
+			if !seen[src] {
+				seen[src] = true
+				review.Images = append(review.Images, src)
+			}
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews
+}
+
+// Parse the rating totals and average from a review page, zero when it has no header.
+// The count line reads e.g. "1,234 global ratings | 567 with reviews" or only "1,234 global ratings"
+func parseReviewSummary(html string) ReviewSummary {
+	summary := ReviewSummary{}
+	doc := soup.HTMLParse(html)
+
+	for _, hook := range []string{"cr-filter-info-review-rating-count", "total-review-count"} {
+		countElem := doc.Find("", "data-hook", hook)
+		if countElem.Error != nil {
+			continue
+		}
+		counts := regexp.MustCompile(`\d[\d,.\x{00a0}\x{202f}]*`).FindAllString(countElem.FullText(), -1)
+		separators := regexp.MustCompile(`[,.\x{00a0}\x{202f}]`)
+		if len(counts) > 0 {
+			summary.TotalRatings, _ = strconv.Atoi(separators.ReplaceAllString(counts[0], ""))
+		}
+		if len(counts) > 1 {
+			summary.TotalReviews, _ = strconv.Atoi(separators.ReplaceAllString(counts[1], ""))
+		}
+		break
+	}
+
+	// The average reads e.g. "4.5 out of 5" or "4,5 von 5"
+	averageElem := doc.Find("", "data-hook", "rating-out-of-text")
+	if averageElem.Error == nil {
+		if match := regexp.MustCompile(`\d(?:[.,]\d)?`).FindString(averageElem.FullText()); match != "" {
+			summary.Average, _ = strconv.ParseFloat(strings.Replace(match, ",", ".", 1), 64)
+		}
+	}
+
+	return summary
+}
+
+// Get the variation parts of a review's format strip joined with " | ", leaving
+// out the verified purchase badge some layouts put inside the strip
+func parseFormatStrip(elem soup.Root) string {
+	var parts []string
+	var segment strings.Builder
+	flush := func() {
+		for _, part := range strings.Split(segment.String(), "|") {
+			if part = cleanText(part); part != "" && !strings.EqualFold(part, "Verified Purchase") {
+				parts = append(parts, part)
+			}
+		}
+		segment.Reset()
+	}
+
+	// Parts are separated by "|" or by separator elements, so each element ends the current part
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.TextNode:
+				segment.WriteString(child.Data)
+			case html.ElementNode:
+				flush()
+				if !hasAttr(child, "data-hook", "avp-badge") {
+					walk(child)
+					flush()
+				}
+			}
+		}
+	}
+	walk(elem.Pointer)
+	flush()
+	return strings.Join(parts, " | ")
+}
+
+// Check whether a node has an attribute with the given value
+func hasAttr(n *html.Node, key string, value string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key && attr.Val == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Review date lines per locale, capturing the country and the date, e.g.
+// "Reviewed in the United States on March 5, 2023" or "Rezension aus Deutschland vom 5. März 2023"
+var reviewDatePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^reviewed in (?:the )?(.+?) on (.+)$`),
+	regexp.MustCompile(`(?i)^rezension aus (?:der |dem |den )?(.+?) vom (.+)$`),
+	regexp.MustCompile(`(?i)^comment\S* (?:en |au |aux |à )?(.+?) le (.+)$`),
+}
+
+// Month names in English, German and French (matched lowercase)
+var monthNames = map[string]time.Month{
+	"january": time.January, "february": time.February, "march": time.March, "april": time.April,
+	"may": time.May, "june": time.June, "july": time.July, "august": time.August,
+	"september": time.September, "october": time.October, "november": time.November, "december": time.December,
+	"januar": time.January, "jänner": time.January, "februar": time.February, "märz": time.March,
+	"mai": time.May, "juni": time.June, "juli": time.July, "oktober": time.October, "dezember": time.December,
+	"janvier": time.January, "février": time.February, "mars": time.March, "avril": time.April,
+	"juin": time.June, "juillet": time.July, "août": time.August, "septembre": time.September,
+	"octobre": time.October, "novembre": time.November, "décembre": time.December,
+}
+
+// Parse the date and country out of a review date line, the date is zero when it can't be parsed
+func parseReviewDate(text string) (time.Time, string) {
+	text = strings.TrimSpace(text)
+	country, dateText := "", text
+	for _, pattern := range reviewDatePatterns {
+		if match := pattern.FindStringSubmatch(text); match != nil {
+			// Drop flag emoji some marketplaces put after the country
+			country = strings.TrimSpace(strings.TrimRightFunc(match[1], func(r rune) bool { return r > 0x2000 }))
+			dateText = match[2]
+			break
+		}
+	}
+	return parseLocalizedDate(dateText), country
+}
+
+// Parse an English, German or French date, day-first ("5 March 2023", "5. März 2023",
+// "1er mars 2023", "5 Nov. 2019") or month-first ("March 5, 2023"), zero when it can't be parsed
+func parseLocalizedDate(text string) time.Time {
+	var day, year int
+	var monthName string
+	if match := regexp.MustCompile(`(\d{1,2})(?:\.|er)?\s+(\pL+)\.?\s+(\d{4})`).FindStringSubmatch(text); match != nil {
+		day, _ = strconv.Atoi(match[1])
+		monthName = match[2]
+		year, _ = strconv.Atoi(match[3])
+	} else if match := regexp.MustCompile(`(\pL+)\.?\s+(\d{1,2}),?\s+(\d{4})`).FindStringSubmatch(text); match != nil {
+		monthName = match[1]
+		day, _ = strconv.Atoi(match[2])
+		year, _ = strconv.Atoi(match[3])
+	}
+
+	month, ok := lookupMonth(monthName)
+	if !ok || day < 1 || day > 31 {
+		return time.Time{}
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Look up a full or abbreviated ("Nov", "déc") month name
+func lookupMonth(name string) (time.Month, bool) {
+	name = strings.ToLower(name)
+	if month, ok := monthNames[name]; ok {
+		return month, true
+	}
+	if len([]rune(name)) < 3 {
+		return 0, false
+	}
+	// Abbreviations are shared across the languages, e.g. "mar" or "jun"
+	for full, month := range monthNames {
+		if strings.HasPrefix(full, name) {
+			return month, true
+		}
+	}
+	return 0, false
+}
+
+// Words the supported marketplaces use for a single helpful vote (matched lowercase)
+var singleVoteWords = map[string]bool{
+	"one": true, "eine": true, "une": true, "una": true, "uma": true,
+	"één": true, "een": true, "en": true,
+}
+
+// Parse statements like "1,234 people found this helpful" or "One person found this helpful"
+// in any supported locale, e.g. "A 12 personas les ha parecido esto útil" or "3人のお客様がこれが役に立ったと考えています"
+func parseHelpfulVotes(text string) int {
+	text = strings.TrimSpace(text)
+	if match := regexp.MustCompile(`\d[\d,.\s]*`).FindString(text); match != "" {
+		votes, _ := strconv.Atoi(regexp.MustCompile(`[,.\s]`).ReplaceAllString(match, ""))
+		return votes
+	}
+
+	// The singular form spells the number out, possibly after a preposition ("A una persona...")
+	words := strings.Fields(strings.ToLower(text))
+	for i := 0; i < len(words) && i < 2; i++ {
+		if singleVoteWords[words[i]] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// ValidateSort checks a review sort order case-insensitively, returning ErrInvalidSort for unknown ones
+func ValidateSort(sort string) error {
+	_, err := reviewSortParam(sort)
+	return err
+}
+
+// Map a sort order to Amazon's sortBy value, Amazon only sorts by helpfulness or date,
+// so rating fetches the helpful order and is sorted locally
+func reviewSortParam(sort string) (string, error) {
+	switch strings.ToLower(sort) {
+	case "", "helpful", "top", "rating":
+		return "helpful", nil
+	case "recent":
+		return "recent", nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidSort, sort)
+}
+
+// StreamReviews fetches up to opts.Count reviews of a product and calls fn with each one as
+// its page is parsed, in page order, instead of collecting them all. domain is a domain or
+// region such as "amazon.de" or "uk", empty for opts.Region or else amazon.com. It stops
+// early with the error fn returns, or with ctx's error once ctx is done. With the rating
+// sort reviews arrive in Amazon's helpful order, only FetchReviews sorts them by rating
+func StreamReviews(ctx context.Context, asin string, domain string, opts Options, fn func(Review) error) error {
+	if domain == "" {
+		domain = opts.Region
+	}
+	if domain == "" {
+		domain = "amazon.com"
+	}
+	domain, err := RegionDomain(domain)
+	if err != nil {
+		return err
+	}
+	return streamProductReviews(ctx, asin, domain, opts, nil, fn)
+}
+
+// Get product reviews and the rating totals from the first review page
+func getProductReviews(productID string, domain string, opts Options) ([]Review, ReviewSummary, error) {
+	reviews := []Review{}
+	var summary ReviewSummary
+	err := streamProductReviews(context.Background(), productID, domain, opts, &summary, func(review Review) error {
+		reviews = append(reviews, review)
+		return nil
+	})
+	if errors.Is(err, ErrInvalidSort) {
+		return nil, summary, err
+	}
+
+	// Highest rated first, keeping Amazon's helpful order among equal ratings
+	if strings.EqualFold(opts.Sort, "rating") {
+		slices.SortStableFunc(reviews, func(a, b Review) int {
+			return cmp.Compare(b.Rating, a.Rating)
+		})
+	}
+
+	return reviews, summary, err
+}
+
+// Fetch reviews page by page, passing each one matching the filters to fn until opts.Count have been.
+// summary, unless nil, gets the rating totals from the first page
+func streamProductReviews(ctx context.Context, productID string, domain string, opts Options, summary *ReviewSummary, fn func(Review) error) error {
+	count, sort, concurrency := opts.Count, opts.Sort, opts.Concurrency
+
+	sortParam, err := reviewSortParam(sort)
+	if err != nil {
+		return err
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	maxPages := opts.MaxPages
+	if maxPages < 1 {
+		maxPages = maxReviewPages
+	}
+	if maxPages > maxReviewPagesLimit {
+		slog.Warn("Review page cap too high, limiting it", "max_pages", maxPages, "limit", maxReviewPagesLimit)
+		maxPages = maxReviewPagesLimit
+	}
+	filtering := opts.MinRating > 0 || opts.VerifiedOnly
+
+	// Fetch one page per worker at a time until enough reviews match or a
+	// page comes back empty; pages don't always hold 10 reviews, so the
+	// number needed can't be known up front. maxPages is only a safety cap
+	var failed []string
+	fetched := 0
+	found := 0
+	parsed := 0
+	nextPage := 1
+	for nextPage <= maxPages && found < count {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Only fetch as many pages as the reviews still needed should take, going by
+		// how many reviews matched per page so far rather than a fixed page size
+		lastPage := nextPage + reviewPagesNeeded(count-found, found, parsed, opts) - 1
+		if lastPage > nextPage+concurrency-1 {
+			lastPage = nextPage + concurrency - 1
+		}
+		if lastPage > maxPages {
+			lastPage = maxPages
+		}
+
+		pageReviews, pageErrors := fetchReviewPages(ctx, productID, domain, sortParam, nextPage, lastPage, concurrency, opts, summary)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fetched += lastPage - nextPage + 1
+
+		// Pass the pages on in their original order, skipping failed ones
+		exhausted := false
+		batchFailed := 0
+		for i, pageResult := range pageReviews {
+			if pageErrors[i] != nil {
+				failed = append(failed, fmt.Sprintf("page %d: %v", nextPage+i, pageErrors[i]))
+				batchFailed++
+				if isFatalPageError(pageErrors[i]) {
+					exhausted = true
+				}
+				continue
+			}
+			if len(pageResult) == 0 {
+				exhausted = true
+			}
+			parsed++
+			for _, review := range pageResult {
+				if found >= count {
+					break
+				}
+				if !matchesReviewFilters(review, opts) {
+					continue
+				}
+				if err := fn(review); err != nil {
+					return err
+				}
+				found++
+			}
+		}
+
+		// Later pages would fail the same way when a whole batch did
+		if exhausted || batchFailed == len(pageReviews) {
+			break
+		}
+		nextPage = lastPage + 1
+	}
+
+	if found < count && nextPage > maxPages {
+		slog.Warn("Stopped at the review page limit before reaching the requested count", "asin", productID, "found", found, "requested", count, "max_pages", maxPages)
+	} else if filtering && found < count {
+		slog.Warn("Fewer reviews matched the filters than requested", "asin", productID, "found", found, "requested", count, "pages", fetched)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to fetch %d of %d review pages: %s", len(failed), fetched, strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+// Errors that later pages would hit too: a captcha wall, a robots.txt rule or a product that's gone
+func isFatalPageError(err error) bool {
+	return errors.Is(err, ErrCaptcha) || errors.Is(err, ErrDisallowedByRobots) || errors.Is(err, errPageNotFound)
+}
+
+// Estimate how many more pages hold the remaining reviews, from the reviews matched on
+// the pages parsed so far, or the requested page size before any page has been parsed
+func reviewPagesNeeded(remaining int, found int, parsed int, opts Options) int {
+	perPage := float64(defaultReviewsPerPage)
+	if opts.ReviewsPerPage > 0 {
+		perPage = float64(opts.ReviewsPerPage)
+	}
+	if parsed > 0 {
+		perPage = float64(found) / float64(parsed)
+	}
+	if perPage <= 0 {
+		// Nothing matched yet, the filters may be strict, so fetch a full batch
+		return maxReviewPagesLimit
+	}
+	return int(math.Ceil(float64(remaining) / perPage))
+}
+
+// Check a review against the minimum rating and verified purchase filters
+func matchesReviewFilters(review Review, opts Options) bool {
+	if opts.VerifiedOnly && !review.Verified {
+		return false
+	}
+	return review.Rating >= opts.MinRating
+}
+
+// Fetch review pages first..last through a bounded worker pool, results are indexed from first.
+// summary, unless nil, is filled from page 1 when it's among them
+func fetchReviewPages(ctx context.Context, productID string, domain string, sortParam string, first int, last int, concurrency int, opts Options, summary *ReviewSummary) ([][]Review, []error) {
+	pageReviews := make([][]Review, last-first+1)
+	pageErrors := make([]error, last-first+1)
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range jobs {
+				url := fmt.Sprintf("https://www.%s/product-reviews/%s/?pageNumber=%d&sortBy=%s",
+					domain, productID, page, sortParam)
+				if opts.ReviewsPerPage > 0 {
+					url += fmt.Sprintf("&pageSize=%d", opts.ReviewsPerPage)
+				}
+
+				html, err := fetchHTML(ctx, url, opts)
+				if err != nil {
+					pageErrors[page-first] = err
+					continue
+				}
+				pageReviews[page-first] = parseReviewPage(html)
+				if page == 1 && summary != nil {
+					*summary = parseReviewSummary(html)
+				}
+			}
+		}()
+	}
+
+	for page := first; page <= last; page++ {
+		jobs <- page
+	}
+	close(jobs)
+	wg.Wait()
+
+	return pageReviews, pageErrors
+}
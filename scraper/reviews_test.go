@@ -0,0 +1,159 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Two reviews as Amazon marks them up, the rating only in the icon's hidden text
+const reviewPageFixture = `<div id="cm_cr-review_list">
+<div id="R1AAAAAAAAAAAA" data-hook="review">
+  <span class="a-profile-name">Jane</span>
+  <a data-hook="review-title" href="#"><i data-hook="review-star-rating" class="a-icon a-icon-star a-star-4"><span class="a-icon-alt">4.0 out of 5 stars</span></i><span>Works well</span></a>
+  <span data-hook="review-date">Reviewed in the United States on March 5, 2023</span>
+  <span data-hook="avp-badge">Verified Purchase</span>
+  <span data-hook="review-body"><span>Does the job.</span></span>
+</div>
+<div id="R2BBBBBBBBBBBB" data-hook="review">
+  <span class="a-profile-name">Max</span>
+  <i data-hook="cmps-review-star-rating" class="a-icon a-icon-star a-star-2"><span class="a-icon-alt">2,0 von 5 Sternen</span></i>
+  <span data-hook="review-body"><span>Broke after a week.</span></span>
+</div>
+</div>`
+
+func TestParseReviewPageRating(t *testing.T) {
+	reviews := parseReviewPage(reviewPageFixture)
+	if len(reviews) != 2 {
+		t.Fatalf("parseReviewPage() found %d reviews, want 2", len(reviews))
+	}
+	if reviews[0].Rating != 4 || reviews[1].Rating != 2 {
+		t.Errorf("ratings = %v, %v, want 4, 2", reviews[0].Rating, reviews[1].Rating)
+	}
+
+	// The filters only work when the ratings parse
+	opts := Options{MinRating: 3}
+	if !matchesReviewFilters(reviews[0], opts) || matchesReviewFilters(reviews[1], opts) {
+		t.Error("-min-rating 3 should keep the 4 star review and drop the 2 star one")
+	}
+	opts = Options{VerifiedOnly: true}
+	if !matchesReviewFilters(reviews[0], opts) || matchesReviewFilters(reviews[1], opts) {
+		t.Error("-verified-only should keep only the verified review")
+	}
+}
+
+// Pages that aren't cached fail to connect through the closed proxy port
+var closedProxy = &url.URL{Scheme: "http", Host: "127.0.0.1:1"}
+
+// Cache review pages of a test product so they're served without a request
+func cacheReviewPages(t *testing.T, opts Options, pages ...int) {
+	t.Helper()
+	for _, page := range pages {
+		url := fmt.Sprintf("https://www.amazon.test/product-reviews/B000TEST01/?pageNumber=%d&sortBy=helpful", page)
+		writeCache(url, reviewPageFixture, opts)
+		if !isCached(url, opts) {
+			t.Fatalf("page %d wasn't cached", page)
+		}
+	}
+}
+
+func TestStreamReviewsStopsAfterFailedBatch(t *testing.T) {
+	opts := Options{Count: 100, Concurrency: 2, MaxPages: 8, RPS: -1, IgnoreRobots: true,
+		CacheDir: t.TempDir(), Proxy: closedProxy}
+	cacheReviewPages(t, opts, 1, 2)
+
+	found := 0
+	err := streamProductReviews(context.Background(), "B000TEST01", "amazon.test", opts, nil, func(Review) error {
+		found++
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to fetch 2 of 4 review pages") {
+		t.Fatalf("streamProductReviews() error = %v, want the second batch to fail and paging to stop", err)
+	}
+	if found != 4 {
+		t.Errorf("streamProductReviews() passed on %d reviews, want the 4 from the cached pages", found)
+	}
+}
+
+func TestStreamReviewsStopsOnDisallowedPage(t *testing.T) {
+	opts := Options{Count: 100, Concurrency: 1, MaxPages: 8, RPS: -1,
+		CacheDir: t.TempDir(), Proxy: closedProxy}
+	cacheReviewPages(t, opts, 1, 2)
+	robotsMu.Lock()
+	robotsCache["www.amazon.test"] = &robotsRules{disallow: []string{"/product-reviews/B000TEST01/?pageNumber=3"}}
+	robotsMu.Unlock()
+	t.Cleanup(func() {
+		robotsMu.Lock()
+		delete(robotsCache, "www.amazon.test")
+		robotsMu.Unlock()
+	})
+
+	err := streamProductReviews(context.Background(), "B000TEST01", "amazon.test", opts, nil, func(Review) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "failed to fetch 1 of 3 review pages") {
+		t.Errorf("streamProductReviews() error = %v, want paging to stop at the disallowed page 3", err)
+	}
+}
+
+func TestParseHelpfulVotes(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"One person found this helpful", 1},
+		{"1 person found this helpful", 1},
+		{"12 people found this helpful", 12},
+		{"1,234 people found this helpful", 1234},
+		{"  2.345 Personen fanden diese Informationen hilfreich  ", 2345},
+		{"Eine Person fand diese Informationen hilfreich", 1},
+		{"Une personne a trouvé cela utile", 1},
+		{"A una persona le ha parecido esto útil", 1},
+		{"A 12 personas les ha parecido esto útil", 12},
+		{"3人のお客様がこれが役に立ったと考えています", 3},
+		{"Helpful", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := parseHelpfulVotes(tt.text); got != tt.want {
+			t.Errorf("parseHelpfulVotes(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseReviewPageHelpfulVotes(t *testing.T) {
+	page := `<div id="R3CCCCCCCCCCCC" data-hook="review">
+  <span data-hook="review-body"><span>Great.</span></span>
+  <span data-hook="helpful-vote-statement" class="a-size-base a-color-tertiary cr-vote-text">One person found this helpful</span>
+</div>`
+	reviews := parseReviewPage(page)
+	if len(reviews) != 1 || reviews[0].HelpfulVotes != 1 {
+		t.Errorf("parseReviewPage() = %+v, want one review with 1 helpful vote", reviews)
+	}
+}
+
+func TestValidateSort(t *testing.T) {
+	for _, sort := range []string{"", "helpful", "top", "rating", "recent", "Recent", "HELPFUL"} {
+		if err := ValidateSort(sort); err != nil {
+			t.Errorf("ValidateSort(%q) error: %v", sort, err)
+		}
+	}
+	for _, sort := range []string{"newest", "oldest", "price", " recent"} {
+		if err := ValidateSort(sort); !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("ValidateSort(%q) error = %v, want ErrInvalidSort", sort, err)
+		}
+	}
+}
+
+func TestParseReviewPageCleansDate(t *testing.T) {
+	page := `<div id="R4DDDDDDDDDDDD" data-hook="review">
+  <span data-hook="review-date">Reviewed in the United Kingdom
+    on 5 March 2023</span>
+  <span data-hook="review-body"><span>Fine.</span></span>
+</div>`
+	reviews := parseReviewPage(page)
+	if len(reviews) != 1 || reviews[0].Date != "Reviewed in the United Kingdom on 5 March 2023" {
+		t.Errorf("parseReviewPage() = %+v, want the date on one line", reviews)
+	}
+}
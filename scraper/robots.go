@@ -0,0 +1,144 @@
+package scraper
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrDisallowedByRobots is returned when robots.txt disallows fetching a URL
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// Allow and Disallow path patterns from the robots.txt group that applies to us
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// Parsed robots.txt per host, fetched once per run and shared by all workers
+var (
+	robotsMu    sync.Mutex
+	robotsCache = make(map[string]*robotsRules)
+)
+
+// Check whether robots.txt allows fetching a URL, fetching and caching the rules for its host on first use
+func robotsAllowed(pageURL string, opts Options) (bool, error) {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return false, err
+	}
+
+	robotsMu.Lock()
+	rules, cached := robotsCache[parsed.Host]
+	if !cached {
+		rules, err = fetchRobots(parsed.Scheme+"://"+parsed.Host+"/robots.txt", opts)
+		if err != nil {
+			// Without readable rules there's nothing to honor
+			slog.Warn("Couldn't read robots.txt, allowing all paths", "host", parsed.Host, "error", err)
+			rules = &robotsRules{}
+		}
+		robotsCache[parsed.Host] = rules
+	}
+	robotsMu.Unlock()
+
+	return rules.allows(parsed.RequestURI()), nil
+}
+
+// Fetch and parse a robots.txt file
+func fetchRobots(robotsURL string, opts Options) (*robotsRules, error) {
+	client := createHTTPClient(opts)
+	req, err := createRequest(robotsURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+	}
+
+	reader, err := decompressBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return parseRobots(reader), nil
+}
+
+// Parse the rules of the "User-agent: *" group. Requests carry browser
+// User-Agents, so no crawler-specific group ever applies to us.
+func parseRobots(reader io.Reader) *robotsRules {
+	rules := &robotsRules{}
+	inGroup, groupHasRules := false, false
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive User-agent lines share the group that follows them
+			if groupHasRules {
+				inGroup, groupHasRules = false, false
+			}
+			if value == "*" {
+				inGroup = true
+			}
+		case "allow", "disallow":
+			groupHasRules = true
+			if !inGroup || value == "" {
+				continue
+			}
+			if key == "allow" {
+				rules.allow = append(rules.allow, value)
+			} else {
+				rules.disallow = append(rules.disallow, value)
+			}
+		}
+	}
+	return rules
+}
+
+// Check a path against the rules, the longest matching pattern wins and Allow wins ties
+func (r *robotsRules) allows(path string) bool {
+	longestAllow, longestDisallow := -1, -1
+	for _, pattern := range r.allow {
+		if robotsPatternMatches(pattern, path) && len(pattern) > longestAllow {
+			longestAllow = len(pattern)
+		}
+	}
+	for _, pattern := range r.disallow {
+		if robotsPatternMatches(pattern, path) && len(pattern) > longestDisallow {
+			longestDisallow = len(pattern)
+		}
+	}
+	return longestDisallow < 0 || longestAllow >= longestDisallow
+}
+
+// Match a robots.txt path pattern, supporting the * wildcard and $ end anchor
+func robotsPatternMatches(pattern string, path string) bool {
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if strings.HasSuffix(expr, `\$`) {
+		expr = strings.TrimSuffix(expr, `\$`) + "$"
+	}
+	matched, _ := regexp.MatchString(expr, path)
+	return matched
+}
@@ -0,0 +1,264 @@
+// Package scraper fetches and parses Amazon product pages and reviews.
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrInvalidURL is returned when no product ID can be extracted from a URL
+var ErrInvalidURL = errors.New("invalid Amazon URL or couldn't extract product ID")
+
+// ErrNoASIN is returned when a URL matches none of the product URL patterns,
+// it wraps ErrInvalidURL so either can be checked with errors.Is
+var ErrNoASIN = fmt.Errorf("%w: no ASIN in URL", ErrInvalidURL)
+
+// Options controls how products and reviews are fetched
+type Options struct {
+	// Count is the number of reviews to fetch
+	Count int
+	// Sort orders reviews by helpful, recent or rating
+	Sort string
+	// Region overrides the domain taken from the URL, e.g. "de" or "amazon.co.uk"
+	Region string
+	// MinRating drops reviews rated below it, 0 keeps all
+	MinRating float64
+	// VerifiedOnly drops reviews that aren't verified purchases
+	VerifiedOnly bool
+	// Concurrency is the number of review pages, or review images, fetched in parallel per product
+	Concurrency int
+	// Proxy routes every request through the given proxy, nil for a direct connection
+	Proxy *url.URL
+	// Proxies is a pool used instead of Proxy, moving on to the next one whenever a captcha is served
+	Proxies []*url.URL
+	// Retries is how many times a page that came back as a captcha is retried
+	// with the next proxy and a fresh User-Agent, 0 disables retrying. The re-fetch
+	// for RetryOnEmptyPrice counts as one of them
+	Retries int
+	// Cookies is shared by every request so session cookies carry over between
+	// the product and review pages, nil uses a jar shared by the whole process. See NewCookieJar
+	Cookies http.CookieJar
+	// Timeout limits every HTTP exchange, from connecting to reading the body,
+	// including robots.txt and short links, 0 means the default of 30s
+	Timeout time.Duration
+	// RequestTimeout is a shorter deadline for each product and review page fetch,
+	// so deep review paging fails fast on a slow page, 0 leaves only Timeout
+	RequestTimeout time.Duration
+	// RetryOnEmptyPrice re-fetches the product page once with a fresh User-Agent when the title is
+	// found but the price is missing, as long as Retries allows it
+	RetryOnEmptyPrice bool
+	// UserAgent pins the User-Agent sent with every request, as does a User-Agent in Headers
+	UserAgent string
+	// UserAgents is the pool to pick from when UserAgent is empty, defaults to DefaultUserAgents
+	UserAgents []string
+	// RandomUA picks a random User-Agent from the pool per request instead of always the first
+	RandomUA bool
+	// MaxPages caps the review pages fetched per product independently of Count,
+	// 0 means the default of 10 and values above 100 are limited to 100
+	MaxPages int
+	// ReviewsPerPage asks Amazon for this many reviews per page with the pageSize
+	// parameter, where it's honoured, 0 leaves Amazon's default of 10
+	ReviewsPerPage int
+	// IgnoreRobots skips the robots.txt check before each request
+	IgnoreRobots bool
+	// MaxRelated caps RelatedASINs, 0 means the default of 50 and a negative value keeps all
+	MaxRelated int
+	// Headers are set on every request after the defaults, overriding them, e.g. Cookie or User-Agent
+	Headers http.Header
+	// RPS is the request rate shared by all requests in the process, 0 means the
+	// default of 0.5 per second and a negative value disables the limit
+	RPS float64
+	// CacheDir stores fetched pages on disk and serves them from there, empty disables caching
+	CacheDir string
+	// CacheTTL is how long cached pages stay fresh, 0 keeps them forever
+	CacheTTL time.Duration
+	// ConvertTo is a currency code such as "USD" to convert product prices to using Rates
+	ConvertTo string
+	// Rates provides the exchange rates for ConvertTo, see StaticRates and APIRates
+	Rates RateSource
+	// ProductCacheSize keeps up to this many products from FetchProduct in memory,
+	// so repeated calls for the same ASIN and domain skip the fetch, 0 disables it
+	ProductCacheSize int
+	// ProductCacheTTL is how long products stay in memory, 0 keeps them until evicted
+	ProductCacheTTL time.Duration
+}
+
+// FetchProduct fetches the product details for an Amazon product URL
+func FetchProduct(url string, opts Options) (Product, error) {
+	productID, domain, err := resolveProduct(url, opts)
+	if err != nil {
+		return Product{}, err
+	}
+
+	// The cache holds the page as parsed, the options are applied to a copy on every call
+	product, ok := getCachedProduct(productID, domain, opts)
+	if !ok {
+		slog.Info("Scraping product", "asin", productID, "domain", domain)
+
+		product, err = fetchProductPage(productID, domain, opts)
+		if err != nil {
+			return product, err
+		}
+		if !isEmptyProduct(product) {
+			putCachedProduct(productID, domain, product, opts)
+		}
+	}
+	return finishProduct(product, opts)
+}
+
+// FetchReviews fetches up to opts.Count reviews for an Amazon product URL
+func FetchReviews(url string, opts Options) ([]Review, error) {
+	productID, domain, err := resolveProduct(url, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, _, err := getProductReviews(productID, domain, opts)
+	return reviews, err
+}
+
+// FetchReviewsWithSummary is FetchReviews that also returns the product's rating totals
+// from the review page header, showing how much of the whole the fetched reviews are
+func FetchReviewsWithSummary(url string, opts Options) ([]Review, ReviewSummary, error) {
+	productID, domain, err := resolveProduct(url, opts)
+	if err != nil {
+		return nil, ReviewSummary{}, err
+	}
+
+	return getProductReviews(productID, domain, opts)
+}
+
+// FetchReviewRecords is FetchReviews with each review tagged with the product's ASIN and domain
+func FetchReviewRecords(url string, opts Options) ([]ReviewRecord, error) {
+	productID, domain, err := resolveProduct(url, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, _, err := getProductReviews(productID, domain, opts)
+	records := make([]ReviewRecord, 0, len(reviews))
+	for _, review := range reviews {
+		records = append(records, ReviewRecord{ASIN: productID, Domain: domain, Review: review})
+	}
+	return records, err
+}
+
+// ResolveURL returns the canonical product page URL for an Amazon URL, short link
+// or bare ASIN, following short link redirects and applying the region override
+func ResolveURL(url string, opts Options) (string, error) {
+	productID, domain, err := resolveProduct(url, opts)
+	if err != nil {
+		return "", err
+	}
+	return productPageURL(productID, domain), nil
+}
+
+// Get the ASIN and domain to scrape for a URL, applying the region override
+func resolveProduct(url string, opts Options) (string, string, error) {
+	// Short links only carry the ASIN after their redirects are followed
+	if isShortLink(url) {
+		resolved, err := resolveShortLink(url, opts)
+		if err != nil {
+			return "", "", err
+		}
+		_, _, err = getProductIDAndDomain(resolved)
+		if err != nil || isShortLink(resolved) {
+			return "", "", fmt.Errorf("%w: short link %s resolved to %s, which isn't a product page", ErrNoASIN, url, resolved)
+		}
+		url = resolved
+	}
+
+	productID, domain, err := getProductIDAndDomain(url)
+	if err != nil {
+		return "", "", err
+	}
+	
+	// Override domain if region flag is provided
+	if opts.Region != "" {
+		domain, err = RegionDomain(opts.Region)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return productID, domain, nil
+}
+
+// Get product ID and domain from an Amazon URL or bare ASIN, ErrNoASIN when no product pattern matches
+func getProductIDAndDomain(rawURL string) (string, string, error) {
+	// A bare ASIN is scraped on amazon.com unless a region overrides it
+	if asin := strings.TrimSpace(rawURL); regexp.MustCompile(`^[A-Z0-9]{10}$`).MatchString(asin) {
+		return asin, "amazon.com", nil
+	}
+
+	// Match ASIN patterns against the path of Amazon URLs from any region, the host in any
+	// case and with an optional port. Other hosts never count, even with an Amazon URL in the path
+	var patterns []string
+	parsed, err := parseLooseURL(rawURL)
+	if err == nil {
+		host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+		switch {
+		case amazonHostDomain(host) != "":
+			patterns = []string{
+				`^/([A-Za-z0-9-]+/)?dp/([A-Z0-9]{10})`,
+				`^/gp/product/([A-Z0-9]{10})`,
+				`^/([A-Za-z0-9-]+/)?product/([A-Z0-9]{10})`,
+			}
+		case shortLinkHosts[host]:
+			patterns = []string{`^/([A-Z0-9]{10})`}
+		}
+	}
+
+	domain := urlDomain(rawURL)
+	for _, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		match := re.FindStringSubmatch(parsed.Path)
+		if len(match) > 0 {
+			// Return the last capture group which contains the ASIN
+			return match[len(match)-1], domain, nil
+		}
+	}
+	return "", domain, fmt.Errorf("%w: %s", ErrNoASIN, rawURL)
+}
+
+// Parse a URL that may come without its scheme, such as "amazon.de/dp/..."
+func parseLooseURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	return url.Parse(rawURL)
+}
+
+// The marketplace domain of an Amazon host, with subdomains such as www., smile. or m.
+// dropped, or "" when the host isn't one of the marketplaces
+func amazonHostDomain(host string) string {
+	host = strings.ToLower(host)
+	if i := strings.Index(host, ".amazon."); i >= 0 {
+		host = host[i+1:]
+	}
+	if _, ok := marketplaces[host]; !ok {
+		return ""
+	}
+	return host
+}
+
+// Get the marketplace domain from the host of a URL, never from its path, so a slug
+// like /Amazon-Basics-Cable/dp/... can't be mistaken for it. Subdomains such as www.,
+// smile. or m. are dropped, and hosts that aren't Amazon's give amazon.com
+func urlDomain(rawURL string) string {
+	parsed, err := parseLooseURL(rawURL)
+	if err != nil {
+		return "amazon.com"
+	}
+	if domain := amazonHostDomain(parsed.Hostname()); domain != "" {
+		return domain
+	}
+	return "amazon.com"
+}
@@ -0,0 +1,67 @@
+package scraper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetProductIDAndDomain(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		asin   string
+		domain string
+	}{
+		{"dp", "https://www.amazon.com/dp/B08N5WRWNW", "B08N5WRWNW", "amazon.com"},
+		{"dp with slug", "https://www.amazon.com/Echo-Dot/dp/B08N5WRWNW", "B08N5WRWNW", "amazon.com"},
+		{"gp product", "https://www.amazon.com/gp/product/B08N5WRWNW", "B08N5WRWNW", "amazon.com"},
+		{"product", "https://www.amazon.com/Echo-Dot/product/B08N5WRWNW", "B08N5WRWNW", "amazon.com"},
+		{"short link", "https://amzn.to/B08N5WRWNW", "B08N5WRWNW", "amazon.com"},
+		{"bare ASIN", "B08N5WRWNW", "B08N5WRWNW", "amazon.com"},
+		{"query string", "https://www.amazon.de/dp/B08N5WRWNW?th=1&psc=1", "B08N5WRWNW", "amazon.de"},
+		{"ref path and query", "https://www.amazon.co.uk/Echo-Dot/dp/B08N5WRWNW/ref=sr_1_1?keywords=echo", "B08N5WRWNW", "amazon.co.uk"},
+		{"trailing slash", "https://www.amazon.com.br/dp/B08N5WRWNW/", "B08N5WRWNW", "amazon.com.br"},
+		{"fragment", "https://www.amazon.com.tr/dp/B08N5WRWNW#reviews", "B08N5WRWNW", "amazon.com.tr"},
+		{"no scheme", "amazon.co.uk/dp/B08N5WRWNW", "B08N5WRWNW", "amazon.co.uk"},
+		{"no scheme with www", "www.amazon.co.jp/dp/B08N5WRWNW", "B08N5WRWNW", "amazon.co.jp"},
+		{"port", "https://www.amazon.co.uk:443/dp/B08N5WRWNW", "B08N5WRWNW", "amazon.co.uk"},
+		{"upper-case host", "https://WWW.AMAZON.DE/dp/B08N5WRWNW", "B08N5WRWNW", "amazon.de"},
+		{"smile subdomain", "https://smile.amazon.com/gp/product/B08N5WRWNW", "B08N5WRWNW", "amazon.com"},
+		{"mobile subdomain", "https://m.amazon.in/dp/B08N5WRWNW", "B08N5WRWNW", "amazon.in"},
+		{"amazon in slug", "https://www.amazon.co.uk/Amazon-Basics-Cable/dp/B08N5WRWNW", "B08N5WRWNW", "amazon.co.uk"},
+	}
+	for _, domain := range marketplaceDomains() {
+		tests = append(tests,
+			struct{ name, url, asin, domain string }{domain + " dp", "https://www." + domain + "/dp/B08N5WRWNW", "B08N5WRWNW", domain},
+			struct{ name, url, asin, domain string }{domain + " gp product", "https://www." + domain + "/gp/product/B08N5WRWNW/", "B08N5WRWNW", domain},
+		)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asin, domain, err := getProductIDAndDomain(tt.url)
+			if err != nil {
+				t.Fatalf("getProductIDAndDomain(%q) error: %v", tt.url, err)
+			}
+			if asin != tt.asin || domain != tt.domain {
+				t.Errorf("getProductIDAndDomain(%q) = %q, %q, want %q, %q", tt.url, asin, domain, tt.asin, tt.domain)
+			}
+		})
+	}
+}
+
+func TestGetProductIDAndDomainNoASIN(t *testing.T) {
+	for _, url := range []string{
+		"https://www.amazon.com/",
+		"https://www.amazon.com/s?k=echo",
+		"not a url",
+		"https://example.com/amazon.de/dp/B08N5WRWNW",
+		"https://example.com/dp/B08N5WRWNW",
+		"https://www.amazon.com/s?k=echo&ref=amazon.com/dp/B08N5WRWNW",
+		"https://amazon.evil.test.example.com/dp/B08N5WRWNW",
+	} {
+		if _, _, err := getProductIDAndDomain(url); !errors.Is(err, ErrNoASIN) || !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("getProductIDAndDomain(%q) error = %v, want ErrNoASIN", url, err)
+		}
+	}
+}
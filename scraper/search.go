@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/anaskhan96/soup"
+)
+
+// SearchResult represents a product card on a search results page
+type SearchResult struct {
+	ASIN      string `json:"asin" yaml:"asin"`
+	Title     string `json:"title" yaml:"title"`
+	Price     string `json:"price" yaml:"price"`
+	Rating    string `json:"rating" yaml:"rating"`
+	URL       string `json:"url" yaml:"url"`
+	Sponsored bool   `json:"sponsored" yaml:"sponsored"`
+}
+
+// SearchProducts runs a keyword search on the given domain (e.g. "amazon.de" or "de",
+// empty means amazon.com) and returns the results from the first pages result pages
+func SearchProducts(query string, domain string, pages int) ([]SearchResult, error) {
+	return SearchProductsWithOptions(query, domain, pages, Options{})
+}
+
+// SearchProductsWithOptions is SearchProducts fetching with opts, e.g. through a proxy or the page cache
+func SearchProductsWithOptions(query string, domain string, pages int, opts Options) ([]SearchResult, error) {
+	results := []SearchResult{}
+	if domain == "" {
+		domain = "amazon.com"
+	}
+	domain, err := RegionDomain(domain)
+	if err != nil {
+		return results, err
+	}
+	if pages < 1 {
+		pages = 1
+	}
+
+	for page := 1; page <= pages; page++ {
+		searchURL := fmt.Sprintf("https://www.%s/s?k=%s&page=%d", domain, url.QueryEscape(query), page)
+		html, err := fetchHTML(context.Background(), searchURL, opts)
+		if err != nil {
+			return results, fmt.Errorf("search page %d: %w", page, err)
+		}
+
+		pageResults := parseSearchPage(html, domain)
+		if len(pageResults) == 0 {
+			break
+		}
+		results = append(results, pageResults...)
+	}
+
+	return results, nil
+}
+
+// Parse the product cards on a search results page
+func parseSearchPage(html string, domain string) []SearchResult {
+	results := []SearchResult{}
+	doc := soup.HTMLParse(html)
+
+	for _, card := range doc.FindAll("div", "data-component-type", "s-search-result") {
+		result := SearchResult{ASIN: card.Attrs()["data-asin"]}
+		if result.ASIN == "" {
+			continue
+		}
+		result.URL = fmt.Sprintf("https://www.%s/dp/%s", domain, result.ASIN)
+
+		// Extract title
+		titleElem := card.Find("h2")
+		if titleElem.Error == nil {
+			result.Title = cleanText(titleElem.FullText())
+		}
+
+		// Extract price
+		priceElem := card.Find("span", "class", "a-price")
+		if priceElem.Error == nil {
+			offscreenPrice := priceElem.Find("span", "class", "a-offscreen")
+			if offscreenPrice.Error == nil {
+				result.Price = cleanText(offscreenPrice.Text())
+			}
+		}
+
+		// Extract rating from "4.5 out of 5 stars"
+		ratingElem := card.Find("span", "class", "a-icon-alt")
+		if ratingElem.Error == nil {
+			result.Rating = regexp.MustCompile(`^\d+(?:[.,]\d+)?`).FindString(cleanText(ratingElem.Text()))
+		}
+
+		// Sponsored cards carry the AdHolder class or a "Sponsored" label
+		result.Sponsored = strings.Contains(" "+card.Attrs()["class"]+" ", " AdHolder ") ||
+			card.Find("", "class", "puis-sponsored-label-text").Error == nil ||
+			card.Find("", "class", "s-sponsored-label-text").Error == nil
+
+		results = append(results, result)
+	}
+
+	return results
+}
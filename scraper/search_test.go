@@ -0,0 +1,22 @@
+package scraper
+
+import "testing"
+
+func TestParseSearchPage(t *testing.T) {
+	page := `<div data-component-type="s-search-result" data-asin="B08N5WRWNW" class="s-result-item AdHolder">
+  <h2><a href="/dp/B08N5WRWNW"><span>Echo Dot &amp;amp; Clock</span></a></h2>
+  <span class="a-price"><span class="a-offscreen">
+    &amp;#36;49.99
+  </span></span>
+  <i class="a-icon a-icon-star-small"><span class="a-icon-alt">4.7 out of 5 stars</span></i>
+</div>`
+	results := parseSearchPage(page, "amazon.com")
+	if len(results) != 1 {
+		t.Fatalf("parseSearchPage() found %d results, want 1", len(results))
+	}
+	want := SearchResult{ASIN: "B08N5WRWNW", URL: "https://www.amazon.com/dp/B08N5WRWNW",
+		Title: "Echo Dot & Clock", Price: "$49.99", Rating: "4.7", Sponsored: true}
+	if results[0] != want {
+		t.Errorf("parseSearchPage() = %+v, want %+v", results[0], want)
+	}
+}
@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Most redirects followed when resolving a short link
+const maxShortLinkRedirects = 10
+
+// Hosts of Amazon's link shorteners, whose links carry no ASIN and redirect to the product page
+var shortLinkHosts = map[string]bool{
+	"amzn.to":   true,
+	"amzn.eu":   true,
+	"amzn.asia": true,
+	"a.co":      true,
+}
+
+// Resolved short links, shared by all workers so each is only followed once per run
+var (
+	shortLinkMu    sync.Mutex
+	shortLinkCache = make(map[string]string)
+)
+
+// Check whether a URL points at one of Amazon's link shorteners
+func isShortLink(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return shortLinkHosts[strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")]
+}
+
+// Follow a short link's redirects to the product page URL it points at
+func resolveShortLink(shortURL string, opts Options) (string, error) {
+	shortLinkMu.Lock()
+	resolved, cached := shortLinkCache[shortURL]
+	shortLinkMu.Unlock()
+	if cached {
+		return resolved, nil
+	}
+
+	client := createHTTPClient(opts)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxShortLinkRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxShortLinkRedirects)
+		}
+		// Stop at the product page, there's no need to download it here
+		if _, _, err := getProductIDAndDomain(req.URL.String()); err == nil && !isShortLink(req.URL.String()) {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+
+	req, err := createRequest(shortURL, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := waitForRateLimit(context.Background(), shortURL, opts); err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("resolving short link %s: %w", shortURL, err)
+	}
+	resp.Body.Close()
+
+	// The last response is either the final page or the redirect to the product page
+	resolved = resp.Request.URL.String()
+	if location, err := resp.Location(); err == nil {
+		resolved = location.String()
+	}
+	slog.Debug("Resolved short link", "url", shortURL, "resolved", resolved)
+
+	shortLinkMu.Lock()
+	shortLinkCache[shortURL] = resolved
+	shortLinkMu.Unlock()
+	return resolved, nil
+}